@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyHeadEpoch = "meta:chain:head_epoch" // read by the query server instead of a wall-clock estimate
+	headEpochTTL = 2 * time.Hour           // outlives one RUN_EVERY_HOURS=1 cycle; stale heads expire
+)
+
+// publishHeadEpoch stores the Lotus chain head height in Redis. Like the progress reporter it is a
+// no-op without REDIS_ADDR, and without a Lotus connection (CLAIMS_ACTIVE_PROVIDERS_FILE runs), and only
+// logs errors.
+func publishHeadEpoch(ctx context.Context, api v1api.FullNode, rdb *redis.Client) {
+	if rdb == nil || api == nil {
+		return
+	}
+	head, err := api.ChainHead(ctx)
+	if err != nil {
+		log.Warnw("ChainHead failed, head epoch not published", "err", err)
+		return
+	}
+	if err := rdb.Set(ctx, keyHeadEpoch, int64(head.Height()), headEpochTTL).Err(); err != nil {
+		log.Warnw("publish head epoch failed", "key", keyHeadEpoch, "err", err)
+		return
+	}
+	log.Infow("head epoch published", "epoch", head.Height())
+}
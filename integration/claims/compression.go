@@ -0,0 +1,83 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CLAIMS_FILE_COMPRESSION values
+const (
+	compressionAuto = "auto" // by extension: .gz = gzip, .zst = zstd, anything else = none
+	compressionNone = "none"
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+)
+
+func validCompression(mode string) bool {
+	switch mode {
+	case compressionAuto, compressionNone, compressionGzip, compressionZstd:
+		return true
+	}
+	return false
+}
+
+// dumpCompression resolves auto to the codec implied by the file extension
+func dumpCompression(path, mode string) string {
+	if mode != compressionAuto {
+		return mode
+	}
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		return compressionGzip
+	case strings.HasSuffix(path, ".zst"):
+		return compressionZstd
+	}
+	return compressionNone
+}
+
+// dumpReader is the decompressed view of a dump file; Close closes the decoder and the file
+type dumpReader struct {
+	io.Reader
+	closers []func() error
+}
+
+func (d *dumpReader) Close() error {
+	var first error
+	for _, c := range d.closers {
+		if err := c(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// openDumpFile opens path and transparently decompresses it according to mode (see CLAIMS_FILE_COMPRESSION)
+func openDumpFile(path, mode string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	switch codec := dumpCompression(path, mode); codec {
+	case compressionGzip:
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("gzip %s: %w", path, err)
+		}
+		return &dumpReader{Reader: zr, closers: []func() error{zr.Close, f.Close}}, nil
+	case compressionZstd:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("zstd %s: %w", path, err)
+		}
+		return &dumpReader{Reader: zr, closers: []func() error{func() error { zr.Close(); return nil }, f.Close}}, nil
+	default:
+		return f, nil
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// claims_ingest_log statuses
+const (
+	ingestInProgress = "in_progress"
+	ingestCompleted  = "completed"
+	ingestFailed     = "failed"
+
+	ingestLogTimeout = 10 * time.Second
+)
+
+// IngestRecord is one dump file in the ingest log (MONGO_INGEST_LOG_COLL), keyed by the file's cleaned full
+// path: with a DUMP_DIR_GLOB over several directories, dumps of different regions share the same base name
+type IngestRecord struct {
+	Path        string     `bson:"path"`
+	Status      string     `bson:"status"`
+	StartedAt   time.Time  `bson:"started_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty"`
+	Inserted    int        `bson:"inserted"` // new claims upserted by the run that ingested the file
+	Error       string     `bson:"error,omitempty"`
+}
+
+// ingestLog records the outcome of every dump file so a completed file is never ingested twice
+type ingestLog struct {
+	coll *mongo.Collection
+}
+
+func newIngestLog(ctx context.Context, coll *mongo.Collection) ingestLog {
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "path", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_path"),
+	})
+	return ingestLog{coll: coll}
+}
+
+// completed reports whether the file already has a completed record
+func (l ingestLog) completed(ctx context.Context, path string) (bool, error) {
+	n, err := l.coll.CountDocuments(ctx, bson.M{"path": filepath.Clean(path), "status": ingestCompleted})
+	return n > 0, err
+}
+
+// start (re)opens the record of each file as in_progress; a failed or interrupted file starts over
+func (l ingestLog) start(ctx context.Context, paths []string) error {
+	now := time.Now().UTC()
+	for _, p := range paths {
+		_, err := l.coll.UpdateOne(ctx,
+			bson.M{"path": filepath.Clean(p)},
+			bson.M{
+				"$set":   bson.M{"status": ingestInProgress, "started_at": now, "inserted": 0},
+				"$unset": bson.M{"completed_at": "", "error": ""},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish marks the files completed (cause == nil) or failed. It does not use the run's context, so a
+// run cancelled by shutdown is still recorded as failed. Errors are logged only: a missing completed
+// record merely lets the next run upsert the same file again.
+func (l ingestLog) finish(paths []string, inserted int, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ingestLogTimeout)
+	defer cancel()
+	set := bson.M{"status": ingestCompleted, "completed_at": time.Now().UTC(), "inserted": inserted}
+	if cause != nil {
+		set = bson.M{"status": ingestFailed, "error": cause.Error()}
+	}
+	for _, p := range paths {
+		if _, err := l.coll.UpdateOne(ctx, bson.M{"path": filepath.Clean(p)}, bson.M{"$set": set}); err != nil {
+			log.Warnw("ingest log update failed", "file", p, "status", set["status"], "err", err)
+		}
+	}
+}
@@ -2,14 +2,23 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/filecoin-project/go-address"
@@ -18,10 +27,15 @@ import (
 	"github.com/filecoin-project/lotus/api/v1api"
 	"github.com/filecoin-project/lotus/chain/types"
 
+	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
+
+	"storagestats/pkg/claimstore"
+	"storagestats/pkg/env"
+	"storagestats/pkg/model"
 )
 
 /********** Logging **********/
@@ -34,9 +48,23 @@ type cfg struct {
 	MongoURI      string
 	MongoDB       string
 	MongoColl     string
-	DumpDir       string // directory that contains all_claims_YYYYMMDD.json
+	IngestLogColl string // MONGO_INGEST_LOG_COLL: one status record per dump file (see ingest_log.go)
+	DumpDirGlob   string // CLAIMS_DUMP_DIR: directory or filepath.Glob pattern (e.g. /data/claims/*)
+	FilePattern   string // CLAIMS_FILE_PATTERN: dump file name, YYYYMMDD is replaced by today's date
 	BulkSize      int
 	RunEveryHours int
+	MongoWC       string // MONGO_WRITE_CONCERN: "" (driver default), "1", "majority"
+	MongoReadPref string // MONGO_READ_PREFERENCE: "" (driver default), primary, secondaryPreferred, ...
+	FindMaxTimeMS int    // MONGO_AGGREGATION_CURSOR_TIMEOUT_MS: maxTimeMS of the existing-keys find (0 = none)
+	RequireSHA256 bool   // CLAIMS_REQUIRE_CHECKSUM: skip the run when the .sha256 sidecar is missing
+	Network       string // FILECOIN_NETWORK: mainnet (f0 addresses) or calibnet (t0 addresses)
+	ProvidersFile string // CLAIMS_ACTIVE_PROVIDERS_FILE: CSV (provider_id,miner_addr) used instead of Lotus
+	AutoPruneDays int    // CLAIMS_AUTO_PRUNE_DAYS: prune expired/soft-deleted claims older than N days after each ingest (0 = off)
+	RetentionDays int    // CLAIMS_DUMP_RETENTION_DAYS: keep processed dumps in processed/ for N days (0 = delete immediately)
+	RedisAddr     string // REDIS_ADDR: where meta:ingest:progress and meta:chain:head_epoch are published (empty = neither)
+	RedisDB       int
+	Compression   string // CLAIMS_FILE_COMPRESSION: auto (by extension), none, gzip or zstd
+	TelemetrySink string // TELEMETRY_SINK: noop (default) or redis (XADD ingest:events, needs REDIS_ADDR)
 }
 
 func mustEnv(key, def string) string {
@@ -64,15 +92,34 @@ func envInt(key string, def int) int {
 }
 
 func loadCfg() cfg {
+	providersFile := os.Getenv("CLAIMS_ACTIVE_PROVIDERS_FILE")
+	lotusURL := os.Getenv("FULLNODE_API_URL")
+	if providersFile == "" {
+		lotusURL = mustEnv("FULLNODE_API_URL", "") // Lotus is only needed to list active providers
+	}
 	return cfg{
-		LotusURL:      mustEnv("FULLNODE_API_URL", ""),
+		LotusURL:      lotusURL,
 		LotusJWT:      os.Getenv("FULLNODE_API_TOKEN"),
 		MongoURI:      mustEnv("MONGO_URI", ""),
 		MongoDB:       mustEnv("MONGO_DB", "filstats"),
 		MongoColl:     mustEnv("MONGO_CLAIMS_COLL", "claims"),
-		DumpDir:       os.Getenv("CLAIMS_DUMP_DIR"),
+		DumpDirGlob:   os.Getenv("CLAIMS_DUMP_DIR"),
+		FilePattern:   mustEnv("CLAIMS_FILE_PATTERN", "all_claims_YYYYMMDD.json"),
 		BulkSize:      envInt("CLAIMS_BULK_SIZE", 2000),
 		RunEveryHours: envInt("RUN_EVERY_HOURS", 1),
+		MongoWC:       os.Getenv("MONGO_WRITE_CONCERN"),
+		MongoReadPref: os.Getenv("MONGO_READ_PREFERENCE"),
+		FindMaxTimeMS: envInt("MONGO_AGGREGATION_CURSOR_TIMEOUT_MS", 0),
+		RequireSHA256: os.Getenv("CLAIMS_REQUIRE_CHECKSUM") == "true",
+		Network:       mustEnv("FILECOIN_NETWORK", model.NetworkMainnet),
+		ProvidersFile: providersFile,
+		AutoPruneDays: envInt("CLAIMS_AUTO_PRUNE_DAYS", 0),
+		RetentionDays: envInt("CLAIMS_DUMP_RETENTION_DAYS", 0),
+		RedisAddr:     os.Getenv("REDIS_ADDR"),
+		RedisDB:       envInt("REDIS_DB", 0),
+		IngestLogColl: mustEnv("MONGO_INGEST_LOG_COLL", "claims_ingest_log"),
+		Compression:   mustEnv("CLAIMS_FILE_COMPRESSION", compressionAuto),
+		TelemetrySink: mustEnv("TELEMETRY_SINK", telemetryNoop),
 	}
 }
 
@@ -90,6 +137,7 @@ type DBClaim struct {
 	Sector     uint64         `bson:"sector"`
 	MinerAddr  string         `bson:"miner_addr,omitempty"`
 	UpdatedAt  time.Time      `bson:"updated_at"`
+	DeletedAt  *time.Time     `bson:"deleted_at,omitempty"` // set when the provider no longer has power
 	Meta       map[string]any `bson:"meta,omitempty"`
 }
 
@@ -107,8 +155,12 @@ func connectLotus(ctx context.Context, url, jwt string) (v1api.FullNode, func(),
 }
 
 /********** Mongo connection & indexes **********/
-func connectMongo(ctx context.Context, uri, db, coll string) (*mongo.Client, *mongo.Collection, error) {
-	mc, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+func connectMongo(ctx context.Context, uri, db, coll, wc, rp string) (*mongo.Client, *mongo.Collection, error) {
+	opts := options.Client().ApplyURI(uri)
+	if err := env.ApplyMongoConsistency(opts, wc, rp); err != nil {
+		return nil, nil, err
+	}
+	mc, err := mongo.Connect(ctx, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -127,8 +179,10 @@ func connectMongo(ctx context.Context, uri, db, coll string) (*mongo.Client, *mo
 	// Auxiliary indexes
 	_, _ = c.Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{Keys: bson.D{{Key: "client_addr", Value: 1}}},
-		{Keys: bson.D{{Key: "miner_addr", Value: 1}}},
+		{Keys: bson.D{{Key: "miner_addr", Value: 1}, {Key: "term_start", Value: -1}}}, // also serves the query server's /miners/<miner_addr>/claims sort
 		{Keys: bson.D{{Key: "updated_at", Value: -1}}},
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}}},
+		{Keys: bson.D{{Key: "claim_id", Value: 1}}, Options: options.Index().SetSparse(true)}, // query server's /deals?deal_id=
 	})
 
 	return mc, c, nil
@@ -148,7 +202,10 @@ func hasNonZeroPower(p *lotusapi.MinerPower) bool {
 }
 
 /********** Load “active providers” (ActorID set) from Lotus **********/
-func loadActiveProviders(ctx context.Context, api v1api.FullNode) (map[uint64]struct{}, error) {
+func loadActiveProviders(ctx context.Context, api v1api.FullNode, providersFile string) (map[uint64]struct{}, error) {
+	if providersFile != "" {
+		return loadActiveProvidersFromCSV(providersFile)
+	}
 	active := make(map[uint64]struct{}, 16384)
 
 	head, err := api.ChainHead(ctx)
@@ -184,19 +241,61 @@ func loadActiveProviders(ctx context.Context, api v1api.FullNode) (map[uint64]st
 	return active, nil
 }
 
+// loadActiveProvidersFromCSV reads "provider_id,miner_addr" lines. The header line is optional,
+// blank lines and "#" comments are skipped, and the miner_addr column is informational only.
+func loadActiveProvidersFromCSV(path string) (map[uint64]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open providers file: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	active := make(map[uint64]struct{}, 1024)
+	for first := true; ; first = false {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read providers file: %w", err)
+		}
+		field := strings.TrimSpace(rec[0])
+		if first && strings.EqualFold(field, "provider_id") {
+			continue // header
+		}
+		id, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			line, _ := r.FieldPos(0)
+			return nil, fmt.Errorf("providers file %s line %d: invalid provider_id %q", path, line, field)
+		}
+		active[id] = struct{}{}
+	}
+	log.Infow("active providers loaded from file", "file", path, "count", len(active))
+	return active, nil
+}
+
 /********** Read all “business unique keys” from DB **********/
-func loadAllClaimKeysFromDB(ctx context.Context, coll *mongo.Collection) (map[string]struct{}, error) {
+func loadAllClaimKeysFromDB(ctx context.Context, coll *mongo.Collection, maxTimeMS int) (map[string]struct{}, error) {
 	keys := make(map[string]struct{}, 1_000_000)
 
-	cur, err := coll.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{
+	opts := options.Find().SetProjection(bson.M{
 		"provider_id": 1,
 		"data_cid":    1,
 		"sector":      1,
 		"term_start":  1,
 		"_id":         0,
-	}))
+	})
+	if maxTimeMS > 0 {
+		opts.SetMaxTime(time.Duration(maxTimeMS) * time.Millisecond)
+	}
+	cur, err := coll.Find(ctx, bson.M{}, opts)
 	if err != nil {
-		return nil, err
+		return nil, findErr(err, maxTimeMS)
 	}
 	defer cur.Close(ctx)
 
@@ -216,11 +315,19 @@ func loadAllClaimKeysFromDB(ctx context.Context, coll *mongo.Collection) (map[st
 		keys[k] = struct{}{}
 	}
 	if err := cur.Err(); err != nil {
-		return nil, err
+		return nil, findErr(err, maxTimeMS)
 	}
 	return keys, nil
 }
 
+// findErr makes a maxTimeMS expiry recognizable in logs
+func findErr(err error, maxTimeMS int) error {
+	if maxTimeMS > 0 && mongo.IsTimeout(err) {
+		return fmt.Errorf("existing keys find exceeded MONGO_AGGREGATION_CURSOR_TIMEOUT_MS=%d: %w", maxTimeMS, err)
+	}
+	return err
+}
+
 /********** Lenient JSON helpers (support Data as object or string; numbers as string or number) **********/
 type cidOrObj string
 
@@ -316,15 +423,33 @@ type rpcAllClaims struct {
 	ID      any                      `json:"id"`
 }
 
-func loadClaimsFromFileFiltered(path string, active map[uint64]struct{}) ([]DBClaim, error) {
-	f, err := os.Open(path)
+// jsonlClaim is one line of a JSONL dump: a claim object carrying its own id
+type jsonlClaim struct {
+	ClaimID i64OrStr `json:"ClaimID"`
+	filecoinClaim
+}
+
+// loadClaimsFromFileFiltered accepts both dump formats, plain or compressed (see openDumpFile): the Lotus RPC envelope
+// ({"jsonrpc": ..., "result": {"<claim_id>": {...}}}) and JSONL (one claim object per line)
+func loadClaimsFromFileFiltered(path string, active map[uint64]struct{}, network, compression string) ([]DBClaim, error) {
+	f, err := openDumpFile(path, compression)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
+	br := bufio.NewReaderSize(f, 1<<20)
+	envelope, err := isRPCEnvelope(br)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if !envelope {
+		log.Infow("dump file is JSONL, streaming it line by line", "file", path)
+		return loadClaimsJSONL(path, br, active, network)
+	}
+
 	var rpc rpcAllClaims
-	dec := json.NewDecoder(f)
+	dec := json.NewDecoder(br)
 	if err := dec.Decode(&rpc); err != nil {
 		return nil, fmt.Errorf("decode %s: %w", path, err)
 	}
@@ -338,28 +463,116 @@ func loadClaimsFromFileFiltered(path string, active map[uint64]struct{}) ([]DBCl
 		}
 		var claimID int64
 		_, _ = fmt.Sscan(claimIDStr, &claimID)
+		out = append(out, toDBClaim(claimID, c, network, now))
+	}
+	return out, nil
+}
 
-		out = append(out, DBClaim{
-			ClaimID:    claimID,
-			ProviderID: int64(c.Provider),
-			ClientID:   int64(c.Client),
-			DataCID:    string(c.Data), // convert from cidOrObj to string
-			Size:       int64(c.Size),
-			TermMin:    int64(c.TermMin),
-			TermMax:    int64(c.TermMax),
-			TermStart:  int64(c.TermStart),
-			Sector:     uint64(c.Sector),
-			MinerAddr:  fmt.Sprintf("f0%d", uint64(c.Provider)),
-			UpdatedAt:  now,
-		})
+// isRPCEnvelope peeks at the start of the file: '{' followed by a "jsonrpc" key means the RPC envelope,
+// anything else is treated as JSONL
+func isRPCEnvelope(br *bufio.Reader) (bool, error) {
+	head, err := br.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	const ws = " \t\r\n"
+	head = bytes.TrimLeft(head, ws)
+	if len(head) == 0 || head[0] != '{' {
+		return false, nil
+	}
+	return bytes.HasPrefix(bytes.TrimLeft(head[1:], ws), []byte(`"jsonrpc"`)), nil
+}
+
+// loadClaimsJSONL decodes one claim per line, so only the filtered claims are kept in memory
+func loadClaimsJSONL(path string, r io.Reader, active map[uint64]struct{}, network string) ([]DBClaim, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4<<20)
+
+	now := time.Now()
+	var out []DBClaim
+	line := 0
+	for sc.Scan() {
+		line++
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		var c jsonlClaim
+		if err := json.Unmarshal(b, &c); err != nil {
+			return nil, fmt.Errorf("decode %s line %d: %w", path, line, err)
+		}
+		if _, ok := active[uint64(c.Provider)]; !ok {
+			continue
+		}
+		out = append(out, toDBClaim(int64(c.ClaimID), c.filecoinClaim, network, now))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s line %d: %w", path, line+1, err)
 	}
 	return out, nil
 }
 
+func toDBClaim(claimID int64, c filecoinClaim, network string, now time.Time) DBClaim {
+	return DBClaim{
+		ClaimID:    claimID,
+		ProviderID: int64(c.Provider),
+		ClientID:   int64(c.Client),
+		DataCID:    string(c.Data), // convert from cidOrObj to string
+		Size:       int64(c.Size),
+		TermMin:    int64(c.TermMin),
+		TermMax:    int64(c.TermMax),
+		TermStart:  int64(c.TermStart),
+		Sector:     uint64(c.Sector),
+		MinerAddr:  model.NormalizeMinerAddr(network, uint64(c.Provider)),
+		UpdatedAt:  now,
+	}
+}
+
 /********** Insert the set difference (no total cap; batched BulkWrite) **********/
-func insertDiffClaims(ctx context.Context, coll *mongo.Collection, chainClaims []DBClaim, existingKeys map[string]struct{}, bulkSize int) (int64, error) {
+
+// DiffStats explains what happened to every claim of one insertDiffClaims call.
+type DiffStats struct {
+	AlreadyInMap      int // skipped: key found in the preloaded existingKeys map
+	Prepared          int // upserts sent to MongoDB
+	UpsertedNew       int // new documents created
+	DuplicateKeyError int // rejected by the unique index (another writer got there first); not a failure
+	OtherError        int // any other write error, or the whole batch failed
+	RetriedOK         int // transient write errors that succeeded when retried one by one
+	RetriedFailed     int // transient write errors whose retry failed too (also counted in OtherError)
+}
+
+// transientWriteCodes are write error codes worth one more attempt: the server was interrupted or
+// stepped down while applying the batch (InterruptedAtShutdown, InterruptedDueToReplStateChange,
+// ShutdownInProgress, PrimarySteppedDown, NotWritablePrimary)
+var transientWriteCodes = map[int]bool{11600: true, 11602: true, 91: true, 189: true, 10107: true}
+
+// retryWrite re-applies one upsert of a failed bulk batch on its own
+func retryWrite(ctx context.Context, coll *mongo.Collection, m mongo.WriteModel, stats *DiffStats) {
+	um, ok := m.(*mongo.UpdateOneModel)
+	if !ok {
+		stats.RetriedFailed++
+		stats.OtherError++
+		return
+	}
+	res, err := coll.UpdateOne(ctx, um.Filter, um.Update, options.Update().SetUpsert(true))
+	switch {
+	case err == nil:
+		stats.RetriedOK++
+		stats.UpsertedNew += int(res.UpsertedCount)
+	case mongo.IsDuplicateKeyError(err):
+		stats.RetriedOK++
+		stats.DuplicateKeyError++
+	default:
+		stats.RetriedFailed++
+		stats.OtherError++
+		log.Warnw("retried write failed", "filter", um.Filter, "err", err)
+	}
+}
+
+func insertDiffClaims(ctx context.Context, coll *mongo.Collection, chainClaims []DBClaim, existingKeys map[string]struct{}, bulkSize int, prog *progressReporter) (DiffStats, error) {
+	var stats DiffStats
 	if len(chainClaims) == 0 {
-		return 0, nil
+		return stats, nil
 	}
 	if bulkSize <= 0 {
 		bulkSize = 2000
@@ -367,29 +580,73 @@ func insertDiffClaims(ctx context.Context, coll *mongo.Collection, chainClaims [
 
 	var (
 		batch      []mongo.WriteModel
-		inserted   int64
-		prepared   int64
 		now        = time.Now()
 		flushBatch = func() error {
 			if len(batch) == 0 {
 				return nil
 			}
-			res, err := coll.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false))
-			batch = batch[:0]
-			if err != nil {
-				// Allow partial success; conservatively count UpsertedCount
-				log.Warnw("BulkWrite returned error (partial success possible)", "err", err)
-			}
+			n := len(batch)
+			sent := batch // kept to look up failed models by WriteError.Index
+			res, err := coll.BulkWrite(ctx, sent, options.BulkWrite().SetOrdered(false))
+			batch = nil
+			defer func() { log.Infow("diff batch done", "batch", n, "stats", stats) }()
+			// With SetOrdered(false) the driver still returns the partial result alongside the error
 			if res != nil {
-				inserted += res.UpsertedCount
+				stats.UpsertedNew += int(res.UpsertedCount)
+			}
+			if err == nil {
+				return nil
+			}
+
+			var bwe mongo.BulkWriteException
+			if !errors.As(err, &bwe) {
+				// Whole batch failed (network, auth, ...)
+				stats.OtherError += n
+				log.Warnw("BulkWrite failed", "batch", n, "err", err)
+				return nil
+			}
+			var dup, failed int
+			var transient []mongo.WriteModel
+			byCode := make(map[int]int)
+			for _, we := range bwe.WriteErrors {
+				if mongo.IsDuplicateKeyError(we) {
+					dup++
+					continue
+				}
+				if transientWriteCodes[we.Code] && we.Index >= 0 && we.Index < n {
+					transient = append(transient, sent[we.Index])
+					continue
+				}
+				failed++
+				byCode[we.Code]++
+			}
+			if bwe.WriteConcernError != nil {
+				log.Warnw("BulkWrite write concern error", "err", bwe.WriteConcernError.Message)
+			}
+			stats.DuplicateKeyError += dup
+			stats.OtherError += failed
+			if failed > 0 {
+				log.Warnw("BulkWrite partial failure",
+					"batch", n, "duplicate_key", dup, "failed", failed, "errors_by_code", byCode)
+			}
+			if len(transient) > 0 {
+				log.Infow("retrying transient write errors one by one", "count", len(transient))
+				for _, m := range transient {
+					retryWrite(ctx, coll, m, &stats)
+				}
 			}
 			return nil
 		}
 	)
 
-	for _, c := range chainClaims {
+	// 1) Diff against the preloaded keys
+	prog.phase(ctx, phaseComputingDiff, len(chainClaims))
+	var pending []mongo.WriteModel
+	for i, c := range chainClaims {
+		prog.update(ctx, i+1)
 		k := claimKey(c.ProviderID, c.DataCID, c.Sector, c.TermStart)
 		if _, ok := existingKeys[k]; ok {
+			stats.AlreadyInMap++
 			continue // already exists
 		}
 		c.UpdatedAt = now
@@ -400,116 +657,421 @@ func insertDiffClaims(ctx context.Context, coll *mongo.Collection, chainClaims [
 			"term_start":  c.TermStart,
 		}
 		update := bson.M{"$setOnInsert": c}
-		batch = append(batch, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
-		prepared++
+		pending = append(pending, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+		stats.Prepared++
+	}
 
+	// 2) Upsert in batches of bulkSize
+	prog.phase(ctx, phaseBulkWrite, len(pending))
+	for i, m := range pending {
+		batch = append(batch, m)
 		if len(batch) >= bulkSize {
 			if err := flushBatch(); err != nil {
-				return inserted, err
+				return stats, err
 			}
 		}
+		prog.update(ctx, i+1)
 	}
 	if err := flushBatch(); err != nil {
-		return inserted, err
+		return stats, err
 	}
 
-	log.Infow("diff insert finished", "prepared", prepared, "upserted", inserted, "bulkSize", bulkSize)
-	return inserted, nil
+	log.Infow("diff insert finished",
+		"already_in_map", stats.AlreadyInMap,
+		"prepared", stats.Prepared,
+		"upserted_new", stats.UpsertedNew,
+		"duplicate_key_error", stats.DuplicateKeyError,
+		"other_error", stats.OtherError,
+		"retried_ok", stats.RetriedOK,
+		"retried_failed", stats.RetriedFailed,
+		"bulkSize", bulkSize)
+	return stats, nil
 }
 
-/********** Single run: ensure the dump file exists and is stable, then proceed **********/
-func runFromTodayDumpOnce(ctx context.Context, api v1api.FullNode, coll *mongo.Collection, dumpDir string, bulkSize int) error {
-	startAt := time.Now()
-	log.Infow("run start", "start_at", startAt.Format(time.RFC3339))
+/********** Soft-delete claims whose provider is no longer active **********/
+func markStaleClaims(ctx context.Context, coll *mongo.Collection, active map[uint64]struct{}) (int64, int64, error) {
+	ids := make([]int64, 0, len(active))
+	for id := range active {
+		ids = append(ids, int64(id))
+	}
+	now := time.Now()
 
-	dateStr := time.Now().Format("20060102")
-	if dumpDir == "" {
-		dumpDir = "."
+	// Providers that lost power: stamp deleted_at once (keep the original timestamp on later runs)
+	res, err := coll.UpdateMany(ctx,
+		bson.M{"provider_id": bson.M{"$nin": ids}, "deleted_at": nil},
+		bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}},
+	)
+	if err != nil {
+		return 0, 0, fmt.Errorf("mark deleted: %w", err)
 	}
-	filePath := filepath.Join(dumpDir, fmt.Sprintf("all_claims_%s.json", dateStr))
+	deleted := res.ModifiedCount
 
-	// 1) Check file existence
-	info, err := os.Stat(filePath)
+	// Providers that regained power: clear the marker
+	res, err = coll.UpdateMany(ctx,
+		bson.M{"provider_id": bson.M{"$in": ids}, "deleted_at": bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{"deleted_at": ""}, "$set": bson.M{"updated_at": now}},
+	)
 	if err != nil {
-		if os.IsNotExist(err) {
-			log.Infow("dump file not found, skip this run (early return)", "file", filePath)
-			return nil
+		return deleted, 0, fmt.Errorf("restore deleted: %w", err)
+	}
+	return deleted, res.ModifiedCount, nil
+}
+
+/********** Dump file verification **********/
+
+// verifyDumpFile reports whether the dump file is complete and can be ingested.
+// A sidecar <file>.sha256 (hex digest, optionally followed by the file name as
+// written by sha256sum) is authoritative; without it we fall back to polling the size.
+func verifyDumpFile(filePath string, size int64, requireChecksum bool) (bool, error) {
+	sumPath := filePath + ".sha256"
+	raw, err := os.ReadFile(sumPath)
+	switch {
+	case err == nil:
+		fields := strings.Fields(string(raw))
+		if len(fields) == 0 {
+			log.Warnw("checksum sidecar is empty, skip this run", "file", sumPath)
+			return false, nil
+		}
+		want := strings.ToLower(fields[0])
+		got, err := fileSHA256(filePath)
+		if err != nil {
+			return false, fmt.Errorf("hash dump file: %w", err)
 		}
-		return fmt.Errorf("stat dump file: %w", err)
+		if got != want {
+			log.Warnw("dump file checksum mismatch, skip this run",
+				"file", filePath, "expected", want, "actual", got)
+			return false, nil
+		}
+		log.Infow("using dump file", "file", filePath, "verification", "sha256")
+		return true, nil
+	case !os.IsNotExist(err):
+		return false, fmt.Errorf("read checksum sidecar: %w", err)
+	case requireChecksum:
+		log.Warnw("checksum sidecar not found and CLAIMS_REQUIRE_CHECKSUM=true, skip this run", "file", sumPath)
+		return false, nil
 	}
 
-	// 2) Check if the file is still being written (size stability)
+	// No sidecar: check if the file is still being written (size stability)
 	const stableCheckInterval = 5 * time.Second
 	const stableCheckRetries = 3
 
-	stable := false
-	prevSize := info.Size()
+	prevSize := size
 	for i := 0; i < stableCheckRetries; i++ {
 		time.Sleep(stableCheckInterval)
-		info2, err := os.Stat(filePath)
+		info, err := os.Stat(filePath)
 		if err != nil {
-			return fmt.Errorf("stat dump file during stability check: %w", err)
+			return false, fmt.Errorf("stat dump file during stability check: %w", err)
 		}
-		if info2.Size() == prevSize {
-			stable = true
-			break
+		if info.Size() == prevSize {
+			log.Infow("using dump file", "file", filePath, "verification", "size_stability")
+			return true, nil
 		}
 		log.Infow("dump file still growing, wait more...",
 			"file", filePath,
 			"prev_size", prevSize,
-			"new_size", info2.Size(),
+			"new_size", info.Size(),
 			"retry", i+1)
-		prevSize = info2.Size()
+		prevSize = info.Size()
 	}
-	if !stable {
-		log.Warnw("dump file not stable, skip this run", "file", filePath)
+	log.Warnw("dump file not stable, skip this run", "file", filePath)
+	return false, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+/********** Dump file discovery **********/
+
+// findTodayDumpFiles expands the dump glob and returns today's dump files, sorted.
+// The glob may match directories (the file name is appended) or files directly
+// (e.g. /data/claims/*/all_claims_*.json); files are kept only if they carry today's name.
+func findTodayDumpFiles(dumpGlob, filePattern string, today time.Time) ([]string, error) {
+	if dumpGlob == "" {
+		dumpGlob = "."
+	}
+	name := strings.ReplaceAll(filePattern, "YYYYMMDD", today.Format("20060102"))
+
+	matches, err := filepath.Glob(dumpGlob)
+	if err != nil {
+		return nil, fmt.Errorf("bad CLAIMS_DUMP_DIR pattern %q: %w", dumpGlob, err)
+	}
+	seen := make(map[string]struct{})
+	var files []string
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		p := m
+		if info.IsDir() {
+			p = filepath.Join(m, name)
+			if _, err := os.Stat(p); err != nil {
+				continue
+			}
+		} else if filepath.Base(m) != name {
+			continue
+		}
+		if filepath.Base(filepath.Dir(p)) == processedDirName {
+			continue // already ingested, kept for retention
+		}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		files = append(files, p)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+/********** Dump retention: processed/ next to each dump **********/
+const processedDirName = "processed"
+
+// retainDumpFile moves a processed dump (and its .sha256 sidecar) to processed/ in the same directory.
+// The mtime is reset so the retention period counts from the ingest.
+func retainDumpFile(filePath string) {
+	dir := filepath.Join(filepath.Dir(filePath), processedDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Warnw("failed to create processed dir", "dir", dir, "err", err)
+		return
+	}
+	now := time.Now()
+	for _, src := range []string{filePath, filePath + ".sha256"} {
+		dst := filepath.Join(dir, filepath.Base(src))
+		if err := os.Rename(src, dst); err != nil {
+			if !os.IsNotExist(err) {
+				log.Warnw("failed to move dump file to processed", "file", src, "err", err)
+			}
+			continue
+		}
+		_ = os.Chtimes(dst, now, now)
+		log.Infow("dump file retained", "file", dst)
+	}
+}
+
+// cleanupProcessedDumps removes files older than retentionDays from the processed/ dir of every
+// directory matched by the dump glob
+func cleanupProcessedDumps(dumpGlob string, retentionDays int, now time.Time) {
+	if dumpGlob == "" {
+		dumpGlob = "."
+	}
+	matches, err := filepath.Glob(dumpGlob)
+	if err != nil {
+		return // reported by findTodayDumpFiles
+	}
+	cutoff := now.AddDate(0, 0, -retentionDays)
+	seen := make(map[string]struct{})
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		dir := m
+		if !info.IsDir() {
+			dir = filepath.Dir(m)
+		}
+		if filepath.Base(dir) == processedDirName {
+			continue
+		}
+		dir = filepath.Join(dir, processedDirName)
+		if _, ok := seen[dir]; ok {
+			continue
+		}
+		seen[dir] = struct{}{}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue // no processed/ yet
+		}
+		for _, e := range entries {
+			fi, err := e.Info()
+			if err != nil || fi.IsDir() || !fi.ModTime().Before(cutoff) {
+				continue
+			}
+			p := filepath.Join(dir, e.Name())
+			if err := os.Remove(p); err != nil {
+				log.Warnw("failed to remove expired dump", "file", p, "err", err)
+				continue
+			}
+			log.Infow("expired dump removed", "file", p, "retention_days", retentionDays)
+		}
+	}
+}
+
+// errNoActiveProviders fails a run whose provider list came back empty: its files were not ingested, so
+// the ingest log must record them failed (retried next run) rather than completed.
+var errNoActiveProviders = errors.New("no active providers found")
+
+/********** Single run: find today's dump files, make sure they are complete, then proceed **********/
+func runFromTodayDumpOnce(ctx context.Context, api v1api.FullNode, coll *mongo.Collection, ilog ingestLog, rdb *redis.Client, sink TelemetrySink, c cfg) (err error) {
+	startAt := time.Now()
+	log.Infow("run start", "start_at", startAt.Format(time.RFC3339))
+
+	// Publish the chain head first: runs without a dump still keep meta:chain:head_epoch fresh
+	publishHeadEpoch(ctx, api, rdb)
+
+	// 0) Drop retained dumps that are past CLAIMS_DUMP_RETENTION_DAYS
+	if c.RetentionDays > 0 {
+		cleanupProcessedDumps(c.DumpDirGlob, c.RetentionDays, startAt)
+	}
+
+	// 1) Find today's dump files
+	candidates, err := findTodayDumpFiles(c.DumpDirGlob, c.FilePattern, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		log.Infow("dump file not found, skip this run (early return)", "glob", c.DumpDirGlob, "pattern", c.FilePattern)
+		return nil
+	}
+
+	// 2) Make sure each file is complete (serially): checksum sidecar if present, size stability otherwise.
+	// Files with a completed record in the ingest log were already ingested and are skipped.
+	var files []string
+	for _, filePath := range candidates {
+		done, err := ilog.completed(ctx, filePath)
+		if err != nil {
+			return fmt.Errorf("read ingest log: %w", err)
+		}
+		if done {
+			log.Infow("dump file already ingested, skip", "file", filePath)
+			continue
+		}
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return fmt.Errorf("stat dump file: %w", err)
+		}
+		ready, err := verifyDumpFile(filePath, info.Size(), c.RequireSHA256)
+		if err != nil {
+			return err
+		}
+		if ready {
+			files = append(files, filePath)
+		}
+	}
+	if len(files) == 0 {
 		return nil
 	}
-	log.Infow("using stable dump file", "file", filePath)
+
+	// From here on the files are in_progress in the ingest log until the run completes or fails
+	if err := ilog.start(ctx, files); err != nil {
+		return fmt.Errorf("write ingest log: %w", err)
+	}
+	inserted := 0
+	defer func() { ilog.finish(files, inserted, err) }()
 
 	// 3) Load active providers
-	active, err := loadActiveProviders(ctx, api)
+	active, err := loadActiveProviders(ctx, api, c.ProvidersFile)
 	if err != nil {
 		return fmt.Errorf("load active providers: %w", err)
 	}
 	if len(active) == 0 {
-		log.Warn("no active providers found; nothing to do")
-		return nil
+		return errNoActiveProviders
 	}
 
-	// 4) Load from file + filter
-	claimsList, err := loadClaimsFromFileFiltered(filePath, active)
-	if err != nil {
-		return err
+	prog := newProgressReporter(rdb)
+	defer prog.clear()
+
+	// 4) Load from files + filter, merged into one slice (same claim in several files counted once)
+	prog.phase(ctx, phaseLoadingClaims, len(files))
+	var claimsList []DBClaim
+	merged := make(map[string]struct{})
+	for i, filePath := range files {
+		fileClaims, err := loadClaimsFromFileFiltered(filePath, active, c.Network, c.Compression)
+		if err != nil {
+			return err
+		}
+		for _, cl := range fileClaims {
+			k := claimKey(cl.ProviderID, cl.DataCID, cl.Sector, cl.TermStart)
+			if _, dup := merged[k]; dup {
+				continue
+			}
+			merged[k] = struct{}{}
+			claimsList = append(claimsList, cl)
+		}
+		log.Infow("claims loaded from file (filtered by active providers)", "file", filePath, "count", len(fileClaims))
+		sink.Record(ctx, "file_loaded", map[string]any{"file": filePath, "claims": len(fileClaims)})
+		prog.update(ctx, i+1)
 	}
-	log.Infow("claims loaded from file (filtered by active providers)", "count", len(claimsList))
+	log.Infow("claims merged", "files", len(files), "count", len(claimsList))
 
 	// 5) Load existing DB key set
-	existingKeys, err := loadAllClaimKeysFromDB(ctx, coll)
+	existingKeys, err := loadAllClaimKeysFromDB(ctx, coll, c.FindMaxTimeMS)
 	if err != nil {
 		return fmt.Errorf("load db keys: %w", err)
 	}
 	log.Infow("loaded db claim keys", "count", len(existingKeys))
 
 	// 6) Upsert the set difference
-	added, err := insertDiffClaims(ctx, coll, claimsList, existingKeys, bulkSize)
+	stats, err := insertDiffClaims(ctx, coll, claimsList, existingKeys, c.BulkSize, prog)
 	if err != nil {
 		return err
 	}
+	inserted = stats.UpsertedNew
 
-	// 7) Remove the dump file after ingest
-	if err := os.Remove(filePath); err != nil {
-		log.Warnw("failed to remove dump file", "file", filePath, "err", err)
-	} else {
-		log.Infow("dump file removed", "file", filePath)
+	// 6.1) Soft-delete claims of providers that are no longer active
+	deleted, restored, err := markStaleClaims(ctx, coll, active)
+	if err != nil {
+		return err
+	}
+	log.Infow("stale claims marked", "deleted", deleted, "restored", restored)
+
+	// 7) Remove the dump files after ingest, or keep them in processed/ when retention is configured
+	for _, filePath := range files {
+		if c.RetentionDays > 0 {
+			retainDumpFile(filePath)
+			continue
+		}
+		if err := os.Remove(filePath); err != nil {
+			log.Warnw("failed to remove dump file", "file", filePath, "err", err)
+		} else {
+			log.Infow("dump file removed", "file", filePath)
+		}
+		if err := os.Remove(filePath + ".sha256"); err != nil && !os.IsNotExist(err) {
+			log.Warnw("failed to remove checksum sidecar", "file", filePath+".sha256", "err", err)
+		}
+	}
+
+	// 8) Hard-delete long expired / soft-deleted claims
+	if c.AutoPruneDays > 0 {
+		pruneStart := time.Now()
+		filter, err := claimstore.PruneFilter("", c.AutoPruneDays, pruneStart)
+		if err != nil {
+			return err
+		}
+		pruned, err := claimstore.Prune(ctx, coll, filter)
+		if err != nil {
+			return fmt.Errorf("prune claims: %w", err)
+		}
+		log.Infow("claims pruned", "older_than_days", c.AutoPruneDays, "deleted", pruned, "took", time.Since(pruneStart).String())
 	}
 
 	endAt := time.Now()
 	log.Infow("run end",
 		"end_at", endAt.Format(time.RFC3339),
 		"took", endAt.Sub(startAt).String(),
-		"added", added,
+		"added", stats.UpsertedNew,
+		"failed", stats.OtherError,
 	)
+	sink.Record(ctx, "ingest_completed", map[string]any{
+		"files":            strings.Join(files, ","),
+		"duration_ms":      endAt.Sub(startAt).Milliseconds(),
+		"prepared":         len(claimsList),
+		"upserted":         stats.UpsertedNew,
+		"failed":           stats.OtherError,
+		"active_providers": len(active),
+		"soft_deleted":     deleted,
+	})
 	return nil
 }
 
@@ -522,33 +1084,66 @@ func main() {
 
 	cfg := loadCfg()
 	log.Infow("boot",
-		"lotus", cfg.LotusURL,
-		"mongo", cfg.MongoURI,
-		"db", cfg.MongoDB, "coll", cfg.MongoColl,
-		"dumpDir", cfg.DumpDir,
+		"lotus", env.MaskURI(cfg.LotusURL),
+		"mongo", env.MaskURI(cfg.MongoURI),
+		"db", cfg.MongoDB, "coll", cfg.MongoColl, "ingestLogColl", cfg.IngestLogColl,
+		"dumpDir", cfg.DumpDirGlob,
+		"filePattern", cfg.FilePattern,
 		"bulkSize", cfg.BulkSize,
 		"runEveryHours", cfg.RunEveryHours,
+		"writeConcern", cfg.MongoWC,
+		"readPreference", cfg.MongoReadPref,
+		"requireChecksum", cfg.RequireSHA256,
+		"network", cfg.Network,
+		"providersFile", cfg.ProvidersFile,
+		"autoPruneDays", cfg.AutoPruneDays,
+		"retentionDays", cfg.RetentionDays,
+		"redis", env.MaskURI(cfg.RedisAddr),
+		"compression", cfg.Compression,
+		"telemetry", cfg.TelemetrySink,
 	)
+	if !validCompression(cfg.Compression) {
+		log.Fatalw("CLAIMS_FILE_COMPRESSION must be auto, none, gzip or zstd", "value", cfg.Compression)
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
-	// lotus
-	full, closeLotus, err := connectLotus(ctx, cfg.LotusURL, cfg.LotusJWT)
-	if err != nil {
-		log.Fatalw("connect lotus failed", "err", err)
+	// lotus (not needed when the active providers come from a CSV file)
+	var full v1api.FullNode
+	if cfg.ProvidersFile == "" {
+		api, closeLotus, err := connectLotus(ctx, cfg.LotusURL, cfg.LotusJWT)
+		if err != nil {
+			log.Fatalw("connect lotus failed", "err", err)
+		}
+		defer closeLotus()
+		full = api
 	}
-	defer closeLotus()
 
 	// mongo
-	mc, claimsColl, err := connectMongo(ctx, cfg.MongoURI, cfg.MongoDB, cfg.MongoColl)
+	mc, claimsColl, err := connectMongo(ctx, cfg.MongoURI, cfg.MongoDB, cfg.MongoColl, cfg.MongoWC, cfg.MongoReadPref)
 	if err != nil {
 		log.Fatalw("connect mongo failed", "err", err)
 	}
 	defer mc.Disconnect(ctx)
+	ilog := newIngestLog(ctx, mc.Database(cfg.MongoDB).Collection(cfg.IngestLogColl))
+
+	// redis (optional: ingest progress, chain head, telemetry)
+	var rdb *redis.Client
+	if cfg.RedisAddr != "" {
+		rdb = redis.NewClient(&redis.Options{Addr: cfg.RedisAddr, DB: cfg.RedisDB})
+		defer rdb.Close()
+		if err := rdb.Ping(ctx).Err(); err != nil {
+			log.Warnw("redis ping failed, progress reporting may not work", "addr", env.MaskURI(cfg.RedisAddr), "err", err)
+		}
+	}
+	sink, err := newTelemetrySink(cfg.TelemetrySink, rdb)
+	if err != nil {
+		log.Fatalw("telemetry sink", "err", err)
+	}
 
 	// Run once immediately
-	if err := runFromTodayDumpOnce(ctx, full, claimsColl, cfg.DumpDir, cfg.BulkSize); err != nil {
+	if err := runFromTodayDumpOnce(ctx, full, claimsColl, ilog, rdb, sink, cfg); err != nil {
 		log.Errorw("first run failed", "err", err)
 	}
 
@@ -567,7 +1162,7 @@ func main() {
 			log.Info("shutting down")
 			return
 		case <-ticker.C:
-			if err := runFromTodayDumpOnce(ctx, full, claimsColl, cfg.DumpDir, cfg.BulkSize); err != nil {
+			if err := runFromTodayDumpOnce(ctx, full, claimsColl, ilog, rdb, sink, cfg); err != nil {
 				log.Errorw("scheduled run failed", "err", err)
 			}
 		}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+
+	"storagestats/pkg/testutil"
+)
+
+func TestInsertDiffClaims_Dedup(t *testing.T) {
+	log = zap.NewNop().Sugar()
+	coll := testutil.TestDatabase(t, testutil.StartTestMongo(t)).Collection("claims")
+	ctx := context.Background()
+
+	claim := func(provider int64, cid string) DBClaim {
+		return DBClaim{ProviderID: provider, DataCID: cid, Sector: 7, TermStart: 100, TermMax: 1000}
+	}
+	key := func(c DBClaim) string { return claimKey(c.ProviderID, c.DataCID, c.Sector, c.TermStart) }
+
+	cases := []struct {
+		name     string
+		stored   []DBClaim // already in the collection
+		preload  []DBClaim // already in the existingKeys map
+		chain    []DBClaim
+		want     DiffStats
+		wantDocs int64
+	}{
+		{
+			name:     "new claims",
+			chain:    []DBClaim{claim(1, "a"), claim(1, "b")},
+			want:     DiffStats{Prepared: 2, UpsertedNew: 2},
+			wantDocs: 2,
+		},
+		{
+			name:     "preloaded keys are skipped",
+			stored:   []DBClaim{claim(1, "a")},
+			preload:  []DBClaim{claim(1, "a")},
+			chain:    []DBClaim{claim(1, "a"), claim(2, "a")},
+			want:     DiffStats{AlreadyInMap: 1, Prepared: 1, UpsertedNew: 1},
+			wantDocs: 2,
+		},
+		{
+			name:     "stored but not preloaded is not duplicated",
+			stored:   []DBClaim{claim(1, "a")},
+			chain:    []DBClaim{claim(1, "a")},
+			want:     DiffStats{Prepared: 1},
+			wantDocs: 1,
+		},
+		{
+			name:     "duplicate in the dump",
+			chain:    []DBClaim{claim(3, "c"), claim(3, "c")},
+			want:     DiffStats{Prepared: 2, UpsertedNew: 1},
+			wantDocs: 1,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := coll.DeleteMany(ctx, bson.M{})
+			require.NoError(t, err)
+			for _, c := range tc.stored {
+				_, err := coll.InsertOne(ctx, c)
+				require.NoError(t, err)
+			}
+			existing := make(map[string]struct{}, len(tc.preload))
+			for _, c := range tc.preload {
+				existing[key(c)] = struct{}{}
+			}
+
+			// bulkSize 1 keeps the duplicate upserts of one dump in separate batches
+			stats, err := insertDiffClaims(ctx, coll, tc.chain, existing, 1, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, stats)
+			n, err := coll.CountDocuments(ctx, bson.M{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantDocs, n)
+		})
+	}
+}
+
+// CLAIMS_ACTIVE_PROVIDERS_FILE runs have no Lotus connection; with REDIS_ADDR set the head publish
+// must be skipped rather than call ChainHead on a nil FullNode.
+func TestPublishHeadEpoch_NoLotus(t *testing.T) {
+	log = zap.NewNop().Sugar()
+	rdb := testutil.StartTestRedis(t)
+	ctx := context.Background()
+
+	require.NotPanics(t, func() { publishHeadEpoch(ctx, nil, rdb) })
+	n, err := rdb.Exists(ctx, keyHeadEpoch).Result()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyIngestProgress  = "meta:ingest:progress" // read by the query server (GET /admin/ingest/progress)
+	progressEvery      = 5000                   // items between two progress writes
+	progressTTL        = 24 * time.Hour         // a crashed run must not leave the key behind forever
+	phaseLoadingClaims = "loading_claims"
+	phaseComputingDiff = "computing_diff"
+	phaseBulkWrite     = "bulk_write"
+	progressSetTimeout = 2 * time.Second
+)
+
+type ingestProgress struct {
+	Phase     string  `json:"phase"`
+	Total     int     `json:"total"`
+	Processed int     `json:"processed"`
+	Pct       float64 `json:"pct"`
+	StartedAt string  `json:"started_at"`
+}
+
+// progressReporter publishes the ingest progress to Redis. A nil reporter (REDIS_ADDR unset) is a no-op,
+// and Redis errors are only logged: progress reporting never fails a run.
+type progressReporter struct {
+	rdb       *redis.Client
+	startedAt time.Time
+	cur       ingestProgress
+	lastSaved int
+}
+
+func newProgressReporter(rdb *redis.Client) *progressReporter {
+	if rdb == nil {
+		return nil
+	}
+	return &progressReporter{rdb: rdb, startedAt: time.Now().UTC()}
+}
+
+// phase switches to a new phase with the given item total and saves it immediately
+func (p *progressReporter) phase(ctx context.Context, name string, total int) {
+	if p == nil {
+		return
+	}
+	p.cur = ingestProgress{Phase: name, Total: total, StartedAt: p.startedAt.Format(time.RFC3339)}
+	p.lastSaved = 0
+	p.save(ctx)
+}
+
+// update records processed items; Redis is written every progressEvery items and at the end of the phase
+func (p *progressReporter) update(ctx context.Context, processed int) {
+	if p == nil {
+		return
+	}
+	p.cur.Processed = processed
+	if processed-p.lastSaved < progressEvery && processed != p.cur.Total {
+		return
+	}
+	p.lastSaved = processed
+	p.save(ctx)
+}
+
+func (p *progressReporter) save(ctx context.Context) {
+	if p.cur.Total > 0 {
+		p.cur.Pct = float64(int(float64(p.cur.Processed)/float64(p.cur.Total)*1000)) / 10 // one decimal
+	}
+	bz, _ := json.Marshal(p.cur)
+	ctx, cancel := context.WithTimeout(ctx, progressSetTimeout)
+	defer cancel()
+	if err := p.rdb.Set(ctx, keyIngestProgress, bz, progressTTL).Err(); err != nil {
+		log.Warnw("save ingest progress failed", "err", err)
+	}
+}
+
+// clear removes the progress key once the run is over
+func (p *progressReporter) clear() {
+	if p == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), progressSetTimeout)
+	defer cancel()
+	if err := p.rdb.Del(ctx, keyIngestProgress).Err(); err != nil {
+		log.Warnw("clear ingest progress failed", "err", err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	telemetryNoop  = "noop"
+	telemetryRedis = "redis"
+
+	streamIngestEvents = "ingest:events" // Redis Stream read by external monitoring
+	ingestEventsMaxLen = 10000           // approximate cap (XADD MAXLEN ~)
+	telemetryTimeout   = 2 * time.Second
+)
+
+// TelemetrySink receives structured ingest events. Implementations must not fail a run: errors are
+// logged and dropped.
+type TelemetrySink interface {
+	Record(ctx context.Context, event string, fields map[string]any)
+}
+
+// NoopSink discards every event (TELEMETRY_SINK=noop, the default).
+type NoopSink struct{}
+
+func (NoopSink) Record(context.Context, string, map[string]any) {}
+
+// RedisSink appends events to the ingest:events stream: XADD ingest:events MAXLEN ~ 10000 * event <name> at <RFC3339> <fields...>
+type RedisSink struct {
+	rdb *redis.Client
+}
+
+func (s RedisSink) Record(ctx context.Context, event string, fields map[string]any) {
+	values := make(map[string]any, len(fields)+2)
+	for k, v := range fields {
+		values[k] = v
+	}
+	values["event"] = event
+	values["at"] = time.Now().UTC().Format(time.RFC3339)
+
+	ctx, cancel := context.WithTimeout(ctx, telemetryTimeout)
+	defer cancel()
+	err := s.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamIngestEvents,
+		MaxLen: ingestEventsMaxLen,
+		Approx: true,
+		Values: values,
+	}).Err()
+	if err != nil {
+		log.Warnw("telemetry: xadd failed", "stream", streamIngestEvents, "event", event, "err", err)
+	}
+}
+
+// newTelemetrySink picks the sink named by TELEMETRY_SINK; the redis sink needs REDIS_ADDR
+func newTelemetrySink(name string, rdb *redis.Client) (TelemetrySink, error) {
+	switch name {
+	case "", telemetryNoop:
+		return NoopSink{}, nil
+	case telemetryRedis:
+		if rdb == nil {
+			return nil, fmt.Errorf("TELEMETRY_SINK=redis requires REDIS_ADDR")
+		}
+		return RedisSink{rdb: rdb}, nil
+	}
+	return nil, fmt.Errorf("TELEMETRY_SINK must be redis or noop, got %q", name)
+}
@@ -352,8 +352,9 @@ func (f *FilPlusIntegration) RunOnce(ctx context.Context, documentsOne []model.D
 	}
 	logger.With("waited", time.Since(waitStart)).Info("queue capacity ok")
 
-	tasks, results := util.AddTasks(ctx, f.requester, f.ipInfo, documentsOne, f.locationResolver, f.providerResolver)
-	logger.With("tasks", len(tasks), "results", len(results)).Info("AddTasks generated")
+	tasks, results, stats := util.AddTasks(ctx, f.requester, f.ipInfo, documentsOne, f.locationResolver, f.providerResolver)
+	logger.With("tasks", len(tasks), "results", len(results), "validation_errors", stats.ValidationErrors).
+		Info("AddTasks generated")
 
 	if len(tasks) > 0 {
 		if _, err := f.taskCollection.InsertMany(ctx, tasks); err != nil {
@@ -18,6 +18,26 @@ import (
 
 var logger = logging.Logger("addTasks")
 
+// AddTasksStats summarizes data quality issues found while building tasks.
+type AddTasksStats struct {
+	ValidationErrors int
+}
+
+type addTasksOptions struct {
+	modules []task.ModuleName
+}
+
+// Option configures AddTasks.
+type Option func(*addTasksOptions)
+
+// WithModules makes AddTasks create one task per claim for each of the given modules (default: HTTP only).
+// Modules without an entry in moduleMetadataMap are skipped.
+func WithModules(modules ...task.ModuleName) Option {
+	return func(o *addTasksOptions) {
+		o.modules = modules
+	}
+}
+
 //nolint:nonamedreturns
 func AddTasks(
 	ctx context.Context,
@@ -26,8 +46,31 @@ func AddTasks(
 	documents []model.DBClaim,
 	locationResolver resolver.LocationResolver,
 	providerResolver resolver.ProviderResolver,
-) (tasks []interface{}, results []interface{}) {
+	opts ...Option,
+) (tasks []interface{}, results []interface{}, stats AddTasksStats) {
+	o := addTasksOptions{modules: []task.ModuleName{task.HTTP}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var modules []task.ModuleName
+	for _, module := range o.modules {
+		if _, ok := moduleMetadataMap[module]; !ok {
+			logger.With("module", module).Warn("no metadata for module, skipping it")
+			continue
+		}
+		modules = append(modules, module)
+	}
+
+	validationReasons := make(map[string]int)
+	network := env.GetString(env.FilecoinNetwork, model.NetworkMainnet)
 	for _, document := range documents {
+		// Normalize the miner ID address (f0... on mainnet, t0... on calibnet)
+		if addr, err := model.NormalizeMinerAddrString(network, document.MinerAddr); err == nil {
+			document.MinerAddr = addr
+		} else if document.ProviderID > 0 {
+			document.MinerAddr = model.NormalizeMinerAddr(network, uint64(document.ProviderID))
+		}
+
 		// Resolve provider (using DBClaim.MinerAddr: f0... miner ID address)
 		providerInfo, err := providerResolver.ResolveProvider(ctx, document.MinerAddr)
 		if err != nil {
@@ -62,32 +105,47 @@ func AddTasks(
 			continue
 		}
 
-		// Only add HTTP piece retrieval task (using DataCID)
-		tasks = append(tasks, task.Task{
-			Requester: requester,
-			Module:    task.HTTP,
-			Metadata: map[string]string{
-				"client":        document.ClientAddr,
-				"retrieve_type": "piece",
-				"retrieve_size": "1048576",
-			},
-			Provider: task.Provider{
-				ID:         document.MinerAddr,
-				PeerID:     providerInfo.PeerId,
-				Multiaddrs: convert.MultiaddrsBytesToStringArraySkippingError(providerInfo.Multiaddrs),
-				City:       location.City,
-				Region:     location.Region,
-				Country:    location.Country,
-				Continent:  location.Continent,
-			},
-			Content: task.Content{
-				CID: document.DataCID,
-			},
-			CreatedAt: time.Now().UTC(),
-			Timeout:   env.GetDuration(env.FilplusIntegrationTaskTimeout, 15*time.Second),
-		})
+		// One task per requested module (HTTP piece retrieval by default), all using DataCID
+		for _, module := range modules {
+			metadata := moduleMetadataMap[module]
+			newMetadata := make(map[string]string, len(metadata)+1)
+			for k, v := range metadata {
+				newMetadata[k] = v
+			}
+			newMetadata["client"] = document.ClientAddr
+
+			newTask := task.Task{
+				Requester: requester,
+				Module:    module,
+				Metadata:  newMetadata,
+				Provider: task.Provider{
+					ID:         document.MinerAddr,
+					PeerID:     providerInfo.PeerId,
+					Multiaddrs: convert.MultiaddrsBytesToStringArraySkippingError(providerInfo.Multiaddrs),
+					City:       location.City,
+					Region:     location.Region,
+					Country:    location.Country,
+					Continent:  location.Continent,
+					ASN:        location.ASN,
+					ISP:        location.ISP,
+				},
+				Content:   newContent(document.DataCID, metadata),
+				CreatedAt: time.Now().UTC(),
+				Timeout:   taskTimeout(module),
+			}
+			if err := newTask.Validate(); err != nil {
+				stats.ValidationErrors++
+				validationReasons[err.Error()]++
+				continue
+			}
+			tasks = append(tasks, newTask)
+		}
 	}
 
+	if stats.ValidationErrors > 0 {
+		logger.With("invalid", stats.ValidationErrors, "reasons", validationReasons).
+			Warn("skipped invalid tasks")
+	}
 	logger.With("count", len(tasks)).Info("inserted tasks")
 	//nolint:nakedret
 	return
@@ -97,18 +155,57 @@ func AddTasks(
 var moduleMetadataMap = map[task.ModuleName]map[string]string{
 	task.GraphSync: {
 		"assume_label":  "true",
-		"retrieve_type": "root_block",
+		"retrieve_type": task.RetrieveRootBlock,
 	},
 	task.Bitswap: {
 		"assume_label":  "true",
-		"retrieve_type": "root_block",
+		"retrieve_type": task.RetrieveRootBlock,
 	},
 	task.HTTP: {
-		"retrieve_type": "piece",
-		"retrieve_size": "1048576",
+		"retrieve_type": task.RetrievePiece,
 	},
 }
 
+// Per-module task timeout: env var in milliseconds and default. GraphSync and Bitswap dial and negotiate
+// over libp2p before the first block, so they get more time than an HTTP piece retrieval.
+var moduleTimeoutMap = map[task.ModuleName]struct {
+	key        env.Key
+	defaultVal time.Duration
+}{
+	task.HTTP:      {env.FilplusHTTPTaskTimeoutMS, 15 * time.Second},
+	task.GraphSync: {env.FilplusGraphsyncTaskTimeoutMS, 60 * time.Second},
+	task.Bitswap:   {env.FilplusBitswapTaskTimeoutMS, 60 * time.Second},
+}
+
+// taskTimeout returns the module's FILPLUS_<MODULE>_TASK_TIMEOUT_MS, else the module default. The legacy
+// FILPLUS_INTEGRATION_TASK_TIMEOUT only applies to modules without an entry in moduleTimeoutMap, so it can no
+// longer cut the longer GraphSync/Bitswap defaults down to 15s.
+func taskTimeout(module task.ModuleName) time.Duration {
+	t, ok := moduleTimeoutMap[module]
+	if !ok {
+		return env.GetDuration(env.FilplusIntegrationTaskTimeout, 15*time.Second)
+	}
+	if ms := env.GetInt(t.key, 0); ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return t.defaultVal
+}
+
+const defaultRetrievalSize = 1024 * 1024 // 1 MiB
+
+// newContent builds the task content with the typed retrieve fields; piece retrievals get
+// FILPLUS_INTEGRATION_RETRIEVAL_SIZE bytes (default 1 MiB)
+func newContent(dataCID string, metadata map[string]string) task.Content {
+	content := task.Content{
+		CID:          dataCID,
+		RetrieveType: metadata["retrieve_type"],
+	}
+	if content.RetrieveType == task.RetrievePiece {
+		content.RetrievalSizeBytes = int64(env.GetInt(env.FilplusIntegrationRetrievalSize, defaultRetrievalSize))
+	}
+	return content
+}
+
 func addErrorResults(
 	requester string,
 	ipInfo resolver.IPInfo,
@@ -140,13 +237,13 @@ func addErrorResults(
 					Region:     location.Region,
 					Country:    location.Country,
 					Continent:  location.Continent,
+					ASN:        location.ASN,
+					ISP:        location.ISP,
 				},
-				Content: task.Content{
-					// Always use DataCID
-					CID: document.DataCID,
-				},
+				// Always use DataCID
+				Content:   newContent(document.DataCID, metadata),
 				CreatedAt: time.Now().UTC(),
-				Timeout:   env.GetDuration(env.FilplusIntegrationTaskTimeout, 15*time.Second),
+				Timeout:   taskTimeout(module),
 			},
 			Retriever: task.Retriever{
 				PublicIP:  ipInfo.IP,
@@ -100,7 +100,10 @@ func main() {
 			}
 
 			// Generate tasks (util.AddTasks now supports []model.DBClaim)
-			tasks, results := util.AddTasks(ctx, "oneoff", ipInfo, claims, locationResolver, *providerResolver)
+			tasks, results, stats := util.AddTasks(ctx, "oneoff", ipInfo, claims, locationResolver, *providerResolver)
+			if stats.ValidationErrors > 0 {
+				fmt.Printf("Skipped %d invalid tasks\n", stats.ValidationErrors)
+			}
 
 			if len(results) > 0 {
 				fmt.Println("Errors encountered when creating tasks:")
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"storagestats/pkg/claimstore"
+)
+
+// keyIngestProgress is written by integration/claims while an ingest is running (removed when it ends)
+const keyIngestProgress = "meta:ingest:progress"
+
+// requireAdmin protects an /admin/* handler with ADMIN_API_KEY ("Authorization: Bearer <key>").
+// Without a configured key the admin API is disabled.
+func requireAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if cfg.AdminAPIKey == "" {
+			http.Error(w, "admin API disabled (ADMIN_API_KEY not set)", http.StatusForbidden)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.AdminAPIKey)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// POST /admin/claims/prune?older_than_days=90&status=expired|deleted
+// Hard-deletes claims soft-deleted more than older_than_days ago and/or claims whose term ended more
+// than older_than_days ago (no status = both), in batches of 10,000.
+func handleAdminClaimsPrune(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	days := 90
+	if s := q.Get("older_than_days"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "older_than_days must be an integer", http.StatusBadRequest)
+			return
+		}
+		days = v
+	}
+	start := time.Now()
+	filter, err := claimstore.PruneFilter(q.Get("status"), days, start)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := claimstore.Prune(r.Context(), colClaims, filter)
+	took := time.Since(start)
+	log.Printf("[admin] claims prune status=%q older_than_days=%d: deleted %d in %s (err=%v)", q.Get("status"), days, deleted, took, err)
+	if err != nil {
+		http.Error(w, "mongo prune error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if deleted > 0 {
+		_ = rds.Del(r.Context(), keyClaimsStats).Err()
+	}
+	writeJSON(w, map[string]any{
+		"deleted":     deleted,
+		"duration_ms": took.Milliseconds(),
+	})
+}
+
+// POST /admin/reindex
+// Rebuilds the Redis stats from MongoDB synchronously (even if a scheduled run is in progress) and
+// returns when the run is done.
+func handleAdminReindex(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	log.Printf("[admin] reindex requested")
+	st, err := runOnce(true)
+	if err != nil {
+		http.Error(w, "reindex failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"status":          "completed",
+		"duration_ms":     st.Duration.Milliseconds(),
+		"miners_written":  st.MinersWritten,
+		"clients_written": st.ClientsWritten,
+	})
+}
+
+// GET /admin/ingest/progress
+// Progress of the running claims ingest: {"phase", "total", "processed", "pct", "started_at"};
+// 404 when no ingest is running.
+func handleAdminIngestProgress(w http.ResponseWriter, r *http.Request) {
+	raw, err := rds.Get(r.Context(), keyIngestProgress).Result()
+	if errors.Is(err, redis.Nil) {
+		http.Error(w, "no ingest in progress", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(raw))
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"storagestats/pkg/testutil"
+)
+
+// useTestStores points rds (and colResult if withMongo) at the testutil servers; with withMongo the test is
+// skipped when the MongoDB container can't be started (no Docker)
+func useTestStores(t *testing.T, withMongo bool) {
+	oldCfg, oldRds, oldCol := cfg, rds, colResult
+	t.Cleanup(func() { cfg, rds, colResult = oldCfg, oldRds, oldCol })
+
+	cfg = Config{PipelineBatch: 100, RedisRetries: 1, AggTimeoutMin: 1, Weights: WeightConfig{HTTP: 1}}
+	if withMongo {
+		colResult = testutil.TestDatabase(t, testutil.StartTestMongo(t)).Collection("claims_task_result")
+	}
+	rds = testutil.StartTestRedis(t)
+}
+
+func resultDoc(miner, module, continent string, success bool, at time.Time) bson.M {
+	return bson.M{
+		"task": bson.M{
+			"module":   module,
+			"provider": bson.M{"id": miner, "continent": continent},
+		},
+		"result":     bson.M{"success": success},
+		"created_at": at,
+	}
+}
+
+func TestComputeAndStoreMiner(t *testing.T) {
+	useTestStores(t, true)
+	ctx := context.Background()
+	at := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	cases := []struct {
+		name       string
+		docs       []any
+		wantRates  map[string]float64
+		wantChecks map[string]int64
+		wantCont   map[string]string // miner -> continent ZSET it must be in
+	}{
+		{
+			name: "rate over http checks",
+			docs: []any{
+				resultDoc("f01", "http", "", true, at), resultDoc("f01", "http", "", true, at),
+				resultDoc("f01", "http", "", true, at), resultDoc("f01", "http", "", false, at),
+			},
+			wantRates:  map[string]float64{"f01": 0.75},
+			wantChecks: map[string]int64{"f01": 4},
+		},
+		{
+			name:       "other modules ignored",
+			docs:       []any{resultDoc("f02", "graphsync", "", true, at), resultDoc("f03", "http", "", false, at)},
+			wantRates:  map[string]float64{"f03": 0},
+			wantChecks: map[string]int64{"f03": 1},
+		},
+		{
+			name:       "continent index",
+			docs:       []any{resultDoc("f04", "http", "EU", true, at)},
+			wantRates:  map[string]float64{"f04": 1},
+			wantChecks: map[string]int64{"f04": 1},
+			wantCont:   map[string]string{"f04": "EU"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := colResult.DeleteMany(ctx, bson.M{})
+			require.NoError(t, err)
+			require.NoError(t, rds.FlushDB(ctx).Err())
+			_, err = colResult.InsertMany(ctx, tc.docs)
+			require.NoError(t, err)
+
+			n, err := computeAndStoreMiner(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.wantRates), n)
+			card, _ := rds.ZCard(ctx, zsetMinerHTTP).Result()
+			assert.Equal(t, int64(len(tc.wantRates)), card)
+
+			for miner, rate := range tc.wantRates {
+				val, err := rds.Get(ctx, keyMinerPrefix+miner).Result()
+				require.NoError(t, err, miner)
+				var rd RateDoc
+				require.NoError(t, json.Unmarshal([]byte(val), &rd))
+				assert.InDelta(t, rate, rd.SuccessRateHTTP, 1e-9, miner)
+				assert.Equal(t, tc.wantChecks[miner], rd.TotalHTTP, miner)
+				assert.Equal(t, at.Format(time.RFC3339), rd.LastCheckedAt, miner)
+
+				score, err := rds.ZScore(ctx, zsetMinerHTTP, miner).Result()
+				require.NoError(t, err, miner)
+				assert.InDelta(t, rate, score, 1e-9, miner)
+			}
+			for miner, cont := range tc.wantCont {
+				_, err := rds.ZScore(ctx, zsetMinerCont+cont, miner).Result()
+				assert.NoError(t, err, miner)
+			}
+		})
+	}
+}
+
+func TestHandleMiners_Pagination(t *testing.T) {
+	useTestStores(t, false)
+	ctx := context.Background()
+	// f05 and f06 tie: the page is re-sorted by miner ID ascending
+	scores := map[string]float64{"f01": 0.9, "f02": 0.8, "f03": 0.7, "f04": 0.6, "f05": 0.5, "f06": 0.5}
+	for id, s := range scores {
+		require.NoError(t, rds.ZAdd(ctx, zsetMinerHTTP, redis.Z{Member: id, Score: s}).Err())
+		bz, _ := json.Marshal(RateDoc{SuccessRateHTTP: s})
+		require.NoError(t, rds.Set(ctx, keyMinerPrefix+id, bz, 0).Err())
+	}
+
+	cases := []struct {
+		name     string
+		query    string
+		wantPage int
+		wantSize int
+		wantIDs  []string
+	}{
+		{"first page", "page=1&page_size=2", 1, 2, []string{"f01", "f02"}},
+		{"last page with tie", "page=3&page_size=2", 3, 2, []string{"f05", "f06"}},
+		{"past the end", "page=4&page_size=2", 4, 2, []string{}},
+		{"invalid page size falls back to default", "page_size=0", 1, defaultPageSize, []string{"f01", "f02", "f03", "f04", "f05", "f06"}},
+		{"fuzzy miner_addr", "miner_addr=f0&page=2&page_size=4", 2, 4, []string{"f05", "f06"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handleMiners(rec, httptest.NewRequest("GET", "/miners?"+tc.query, nil))
+			require.Equal(t, 200, rec.Code, rec.Body.String())
+			assert.Equal(t, "6", rec.Header().Get("X-Total-Count"))
+
+			var resp struct {
+				Page     int `json:"page"`
+				PageSize int `json:"page_size"`
+				Total    int `json:"total"`
+				Items    []struct {
+					MinerID string `json:"miner_id"`
+				} `json:"items"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tc.wantPage, resp.Page)
+			assert.Equal(t, tc.wantSize, resp.PageSize)
+			assert.Equal(t, 6, resp.Total)
+			ids := make([]string, 0, len(resp.Items))
+			for _, it := range resp.Items {
+				ids = append(ids, it.MinerID)
+			}
+			assert.Equal(t, tc.wantIDs, ids)
+		})
+	}
+}
+
+func TestHandleClients(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleClients(rec, httptest.NewRequest("GET", "/clients", nil))
+	assert.Equal(t, 400, rec.Code, "client_addr is required")
+
+	useTestStores(t, false)
+	ctx := context.Background()
+	// 30 pairs stored out of order; rates 0.00 .. 0.29
+	list := make([]ClientMinerItem, 0, 30)
+	for i := 0; i < 30; i++ {
+		j := (i * 7) % 30
+		list = append(list, ClientMinerItem{
+			ClientAddr:      "f1test",
+			MinerAddr:       fmt.Sprintf("f0%02d", j),
+			SuccessRateHTTP: float64(j) / 100,
+		})
+	}
+	bz, _ := json.Marshal(list)
+	require.NoError(t, rds.Set(ctx, keyClientPrefix+"f1test", bz, 0).Err())
+
+	type clientsResp struct {
+		Page     int              `json:"page"`
+		PageSize int              `json:"page_size"`
+		Total    int              `json:"total"`
+		Count    *int             `json:"count"`
+		Items    []map[string]any `json:"items"`
+	}
+	get := func(query string) clientsResp {
+		rec := httptest.NewRecorder()
+		handleClients(rec, httptest.NewRequest("GET", "/clients?v=2&"+query, nil))
+		require.Equal(t, 200, rec.Code, rec.Body.String())
+		var resp clientsResp
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	var rates []float64
+	for page := 1; page <= 2; page++ {
+		resp := get(fmt.Sprintf("client_addr=f1test&page=%d", page))
+		assert.Equal(t, page, resp.Page)
+		assert.Equal(t, 15, resp.PageSize)
+		assert.Equal(t, 30, resp.Total)
+		require.Len(t, resp.Items, 15)
+		for _, it := range resp.Items {
+			rates = append(rates, it["success_rate_http"].(float64))
+		}
+	}
+	for i := range rates {
+		assert.InDelta(t, float64(29-i)/100, rates[i], 1e-9, "position %d", i)
+	}
+
+	resp := get("client_addr=f1unknown")
+	require.NotNil(t, resp.Count)
+	assert.Equal(t, 0, *resp.Count)
+	assert.NotNil(t, resp.Items)
+	assert.Empty(t, resp.Items)
+}
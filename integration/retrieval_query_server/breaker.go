@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	breakerMaxRequests = 5 // probes let through while half-open
+	breakerInterval    = 60 * time.Second
+	breakerTimeout     = 30 * time.Second // open -> half-open; also the Retry-After of a 503
+)
+
+// Separate breakers: a failing cron aggregation must not take /details down with it, and vice versa
+var (
+	detailsBreaker = newMongoBreaker("mongo_details")
+	cronBreaker    = newMongoBreaker("mongo_cron")
+)
+
+func newMongoBreaker(name string) *gobreaker.CircuitBreaker {
+	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        name,
+		MaxRequests: breakerMaxRequests,
+		Interval:    breakerInterval,
+		Timeout:     breakerTimeout,
+		// A client hanging up is not a MongoDB failure
+		IsSuccessful: func(err error) bool { return err == nil || errors.Is(err, context.Canceled) },
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			log.Printf("[breaker] %s: %s -> %s", name, from, to)
+		},
+	})
+}
+
+// withBreaker runs fn through cb; it fails fast with gobreaker.ErrOpenState/ErrTooManyRequests while open
+func withBreaker(cb *gobreaker.CircuitBreaker, fn func() error) error {
+	_, err := cb.Execute(func() (interface{}, error) { return nil, fn() })
+	return err
+}
+
+func breakerRejected(err error) bool {
+	return errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests)
+}
+
+// writeBreakerOpen answers 503 with Retry-After while MongoDB is considered unavailable
+func writeBreakerOpen(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(breakerTimeout.Seconds())))
+	http.Error(w, "mongo unavailable, retry later", http.StatusServiceUnavailable)
+}
+
+// cronAggregate opens a cron aggregation cursor on the results collection through cronBreaker
+func cronAggregate(ctx context.Context, pipeline mongo.Pipeline) (*mongo.Cursor, error) {
+	var cur *mongo.Cursor
+	err := withBreaker(cronBreaker, func() (err error) {
+		cur, err = colResult.Aggregate(ctx, pipeline, cronAggregateOptions())
+		return err
+	})
+	return cur, err
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"storagestats/pkg/model"
+)
+
+// /chain/head
+// Current chain epoch from meta:chain:head_epoch (written by integration/claims); source is "clock"
+// when the key is missing and the epoch is estimated from the wall clock.
+func handleChainHead(w http.ResponseWriter, r *http.Request) {
+	epoch, fromChain := readHeadEpoch(r.Context())
+	source := "chain"
+	if !fromChain {
+		source = "clock"
+	}
+	writeJSON(w, map[string]any{
+		"epoch":          epoch,
+		"estimated_time": model.EpochToTime64(epoch).Format(time.RFC3339),
+		"source":         source,
+	})
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	changeStreamFlush      = time.Minute // how often the dirty miners are re-aggregated
+	changeStreamMaxBackoff = time.Minute // longest wait before reopening a failed change stream
+)
+
+// dirtyMiners is the set of miners with new HTTP results since the last refresh
+type dirtyMiners struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+func (d *dirtyMiners) add(id string) {
+	d.mu.Lock()
+	d.ids[id] = struct{}{}
+	d.mu.Unlock()
+}
+
+// take empties the set and returns its miners
+func (d *dirtyMiners) take() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ids := make([]string, 0, len(d.ids))
+	for id := range d.ids {
+		ids = append(ids, id)
+	}
+	d.ids = make(map[string]struct{})
+	return ids
+}
+
+// startChangeStream watches the results collection for inserted HTTP results (CHANGE_STREAM_ENABLED=true)
+// and re-aggregates the affected miners every minute, so their Redis docs do not wait for the next cron run.
+// Change streams need a replica set or sharded cluster.
+func startChangeStream() {
+	if !cfg.ChangeStream {
+		return
+	}
+	dirty := &dirtyMiners{ids: make(map[string]struct{})}
+	go watchResults(dirty)
+	go func() {
+		ticker := time.NewTicker(changeStreamFlush)
+		defer ticker.Stop()
+		for range ticker.C {
+			if cronRunning.Load() > 0 {
+				continue // keep the miners dirty; the full run may have read the collection before their inserts
+			}
+			ids := dirty.take()
+			if len(ids) == 0 {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.AggTimeoutMin)*time.Minute)
+			n, err := refreshMiners(ctx, ids)
+			cancel()
+			if err != nil {
+				log.Printf("[changestream] refresh of %d miners failed: %v", len(ids), err)
+			} else {
+				log.Printf("[changestream] refreshed %d miners", n)
+			}
+		}
+	}()
+}
+
+// watchResults keeps a change stream open on colResult, reopening it after the last seen event on error
+func watchResults(dirty *dirtyMiners) {
+	var resume bson.Raw
+	backoff := time.Second
+	for {
+		seen, err := watchOnce(dirty, &resume)
+		if seen {
+			backoff = time.Second
+		}
+		log.Printf("[changestream] stream closed: %v; reopening in %s", err, backoff)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > changeStreamMaxBackoff {
+			backoff = changeStreamMaxBackoff
+		}
+	}
+}
+
+// watchOnce reads one change stream until it fails and reports whether any event was received.
+// resume is updated after every event.
+func watchOnce(dirty *dirtyMiners, resume *bson.Raw) (seen bool, err error) {
+	ctx := context.Background()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"operationType":            "insert",
+			"fullDocument.task.module": "http",
+		}}},
+		{{Key: "$project", Value: bson.M{"fullDocument.task.provider.id": 1}}},
+	}
+	opts := options.ChangeStream()
+	if *resume != nil {
+		opts.SetResumeAfter(*resume)
+	}
+	cs, err := colResult.Watch(ctx, pipeline, opts)
+	if err != nil {
+		if *resume != nil {
+			// The token may have left the oplog: start from now; the next cron run covers the gap
+			*resume = nil
+		}
+		return false, fmt.Errorf("watch %s: %w", cfg.ResultColl, err)
+	}
+	defer cs.Close(ctx)
+
+	for cs.Next(ctx) {
+		var ev struct {
+			FullDocument struct {
+				Task struct {
+					Provider struct {
+						ID string `bson:"id"`
+					} `bson:"provider"`
+				} `bson:"task"`
+			} `bson:"fullDocument"`
+		}
+		if err := cs.Decode(&ev); err == nil && ev.FullDocument.Task.Provider.ID != "" {
+			dirty.add(ev.FullDocument.Task.Provider.ID)
+		}
+		*resume = cs.ResumeToken()
+		seen = true
+	}
+	return seen, cs.Err()
+}
+
+// refreshMiners re-runs the miner aggregation for ids only and rewrites their docs and ZSET entries.
+// Continent ZSETs a miner has left are only cleaned up by the next full run. Miners without HTTP
+// results are not counted in n.
+func refreshMiners(ctx context.Context, ids []string) (n int, err error) {
+	ctx, span := startSpan(ctx, "cron.refresh miners", attribute.Int("miner_count", len(ids)))
+	defer func() {
+		span.SetAttributes(attribute.Int("result_count", n))
+		endSpan(span, err)
+	}()
+
+	cur, err := cronAggregate(ctx, minerPipeline(bson.M{
+		"task.module":      "http",
+		"task.provider.id": bson.M{"$in": ids},
+	}))
+	if err != nil {
+		return 0, aggErr("miner refresh", err)
+	}
+	defer cur.Close(ctx)
+
+	now := float64(time.Now().Unix())
+	today := time.Now().UTC().Format(snapshotDateLayout)
+	pipe := newBatchPipe()
+	for cur.Next(ctx) {
+		var a aggOut1Key
+		if err := cur.Decode(&a); err != nil {
+			return n, err
+		}
+		if a.ID == "" || a.Total == 0 {
+			continue
+		}
+		if err := queueMinerDoc(ctx, pipe, a, today, now); err != nil {
+			return n, err
+		}
+		n++
+		if err := pipe.maybeFlush(ctx); err != nil {
+			return n, err
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return n, aggErr("miner refresh", err)
+	}
+	return n, pipe.flush(ctx)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	keyClaimsStats = "cache:claims:stats"
+	claimsStatsTTL = 5 * time.Minute
+)
+
+type ClaimsStats struct {
+	TotalClaims     int64  `json:"total_claims" bson:"total_claims"`
+	DistinctMiners  int64  `json:"distinct_miners" bson:"distinct_miners"`
+	DistinctClients int64  `json:"distinct_clients" bson:"distinct_clients"`
+	TotalBytes      int64  `json:"total_bytes" bson:"total_bytes"`
+	MinTermStart    int64  `json:"min_term_start" bson:"min_term_start"`
+	MaxTermStart    int64  `json:"max_term_start" bson:"max_term_start"`
+	ActiveClaims    int64  `json:"active_claims" bson:"active_claims"` // term_start + term_max > current epoch
+	CurrentEpoch    int64  `json:"current_epoch" bson:"-"`
+	ComputedAt      string `json:"computed_at" bson:"-"`
+}
+
+// /claims/stats
+// Overview of the indexed claims (soft-deleted claims excluded), computed in a single $facet pass
+// and cached for 5 minutes.
+func handleClaimsStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cached, err := rds.Get(ctx, keyClaimsStats).Result()
+	if err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(cached))
+		return
+	}
+	if !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	st, err := computeClaimsStats(ctx)
+	if err != nil {
+		http.Error(w, "claims stats error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if bz, err := json.Marshal(st); err == nil {
+		_ = rds.Set(ctx, keyClaimsStats, string(bz), claimsStatsTTL).Err()
+	}
+	writeJSON(w, st)
+}
+
+func computeClaimsStats(ctx context.Context) (ClaimsStats, error) {
+	epoch := headEpoch(ctx)
+	countDistinct := func(field string) bson.A {
+		return bson.A{
+			bson.M{"$group": bson.M{"_id": field}},
+			bson.M{"$count": "n"},
+		}
+	}
+	cur, err := colClaims.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: activeClaims(bson.M{})}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{bson.M{"$group": bson.M{
+				"_id":            nil,
+				"total_claims":   bson.M{"$sum": 1},
+				"total_bytes":    bson.M{"$sum": "$size"},
+				"min_term_start": bson.M{"$min": "$term_start"},
+				"max_term_start": bson.M{"$max": "$term_start"},
+				"active_claims": bson.M{"$sum": bson.M{"$cond": bson.A{
+					bson.M{"$gt": bson.A{bson.M{"$add": bson.A{"$term_start", "$term_max"}}, epoch}}, 1, 0,
+				}}},
+			}}},
+			"miners":  countDistinct("$miner_addr"),
+			"clients": countDistinct("$client_id"),
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return ClaimsStats{}, err
+	}
+	defer cur.Close(ctx)
+
+	var out []struct {
+		Totals  []ClaimsStats       `bson:"totals"`
+		Miners  []struct{ N int64 } `bson:"miners"`
+		Clients []struct{ N int64 } `bson:"clients"`
+	}
+	if err := cur.All(ctx, &out); err != nil {
+		return ClaimsStats{}, err
+	}
+
+	var st ClaimsStats
+	if len(out) > 0 {
+		f := out[0]
+		if len(f.Totals) > 0 {
+			st = f.Totals[0]
+		}
+		if len(f.Miners) > 0 {
+			st.DistinctMiners = f.Miners[0].N
+		}
+		if len(f.Clients) > 0 {
+			st.DistinctClients = f.Clients[0].N
+		}
+	}
+	st.CurrentEpoch = epoch
+	st.ComputedAt = time.Now().UTC().Format(time.RFC3339)
+	return st, nil
+}
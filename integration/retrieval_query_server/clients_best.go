@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultBestK = 5
+
+// GET /clients/<client_addr>/miners/best?k=5&protocol=http
+// Top-K miners of a client by the requested protocol's success rate, read from stats:client:<addr>.
+// confidence is "low" when the pair has fewer than MIN_SAMPLE_CHECKS checks, "high" otherwise.
+func handleClientBestMiners(w http.ResponseWriter, r *http.Request) {
+	client, ok := clientFromPath(r, "/miners/best")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	q := r.URL.Query()
+
+	k := defaultBestK
+	if s := q.Get("k"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxPageSize {
+			http.Error(w, "k must be between 1 and "+strconv.Itoa(maxPageSize), http.StatusBadRequest)
+			return
+		}
+		k = n
+	}
+	protocol := q.Get("protocol")
+	if protocol == "" {
+		protocol = "http"
+	}
+	var rate func(ClientMinerItem) float64
+	switch protocol {
+	case "http":
+		rate = func(it ClientMinerItem) float64 { return it.SuccessRateHTTP }
+	case "graphsync":
+		rate = func(it ClientMinerItem) float64 { return it.SuccessRateGraphsync }
+	case "bitswap":
+		rate = func(it ClientMinerItem) float64 { return it.SuccessRateBitswap }
+	default:
+		http.Error(w, "protocol must be http, graphsync or bitswap", http.StatusBadRequest)
+		return
+	}
+
+	val, err := rds.Get(r.Context(), keyClientPrefix+client).Result()
+	if errors.Is(err, redis.Nil) {
+		http.Error(w, "client not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var list []ClientMinerItem
+	if err := json.Unmarshal([]byte(val), &list); err != nil {
+		http.Error(w, "decode error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Best rate first; more checks win ties
+	sort.SliceStable(list, func(i, j int) bool {
+		if ri, rj := rate(list[i]), rate(list[j]); ri != rj {
+			return ri > rj
+		}
+		return list[i].TotalChecks > list[j].TotalChecks
+	})
+	if len(list) > k {
+		list = list[:k]
+	}
+
+	v := apiVersion(r)
+	items := make([]map[string]any, 0, len(list))
+	for _, it := range list {
+		confidence := "high"
+		if it.TotalChecks < cfg.MinSampleChecks {
+			confidence = "low"
+		}
+		items = append(items, map[string]any{
+			"miner_id":     it.MinerAddr,
+			"success_rate": rateValue(v, rate(it)),
+			"total_checks": it.TotalChecks,
+			"confidence":   confidence,
+		})
+	}
+	writeJSON(w, map[string]any{
+		"client_id": client,
+		"protocol":  protocol,
+		"k":         k,
+		"count":     len(items),
+		"items":     items,
+	})
+}
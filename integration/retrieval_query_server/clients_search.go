@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const zsetClientHTTP = "idx:clients:http" // score = client-level HTTP success rate
+
+// /clients/search?client_addr=&exact=false&page=&page_size=
+// - exact=true: direct GET of stats:client:<client_addr> (at most one item)
+// - otherwise: ZSCAN idx:clients:http with *keyword* (or the pattern as given if it contains "*"),
+// sorted by client-level HTTP success rate (desc), then paginated
+func handleClientsSearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	clientQ := q.Get("client_addr")
+	if clientQ == "" {
+		http.Error(w, "client_addr is required", http.StatusBadRequest)
+		return
+	}
+	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
+	v := apiVersion(r)
+
+	if q.Get("exact") == "true" {
+		val, err := rds.Get(ctx, keyClientPrefix+clientQ).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				writeJSON(w, map[string]any{"page": page, "page_size": pageSize, "total": 0, "items": []any{}})
+				return
+			}
+			http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		score, err := rds.ZScore(ctx, zsetClientHTTP, clientQ).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			http.Error(w, "redis zset error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     1,
+			"items":     []map[string]any{clientItem(clientQ, score, val, v)},
+		})
+		return
+	}
+
+	pattern := clientQ
+	if !strings.Contains(pattern, "*") {
+		pattern = "*" + pattern + "*"
+	}
+	matched, err := zscanAll(ctx, zsetClientHTTP, pattern)
+	if err != nil {
+		http.Error(w, "redis zscan error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Score > matched[j].Score })
+
+	total := int64(len(matched))
+	start := int64((page - 1) * pageSize)
+	if start >= total {
+		writeJSON(w, map[string]any{"page": page, "page_size": pageSize, "total": total, "items": []any{}})
+		return
+	}
+	end := start + int64(pageSize)
+	if end > total {
+		end = total
+	}
+
+	items, err := clientItems(ctx, matched[start:end], v)
+	if err != nil {
+		http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSONStream(w, map[string]any{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total, // Total count of fuzzy matches
+	}, items)
+}
+
+// clientItems loads the miner lists of a page of clients in one pipeline
+func clientItems(ctx context.Context, zs []redis.Z, v int) ([]map[string]any, error) {
+	pipe := rds.Pipeline()
+	cmds := make([]*redis.StringCmd, len(zs))
+	for i, z := range zs {
+		cmds[i] = pipe.Get(ctx, keyClientPrefix+z.Member.(string))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	items := make([]map[string]any, 0, len(zs))
+	for i, z := range zs {
+		val, err := cmds[i].Result()
+		if err != nil {
+			continue // key expired between ZSCAN and GET
+		}
+		items = append(items, clientItem(z.Member.(string), z.Score, val, v))
+	}
+	return items, nil
+}
+
+func clientItem(client string, score float64, listJSON string, v int) map[string]any {
+	var list []ClientMinerItem
+	_ = json.Unmarshal([]byte(listJSON), &list)
+	return map[string]any{
+		"client_id":         client,
+		"success_rate_http": rateValue(v, score),
+		"miner_count":       len(list),
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"storagestats/pkg/claimstore"
+)
+
+const (
+	keyClientSummarySuffix = ":summary" // stats:client:<client_addr>:summary
+	clientSummaryTTL       = 10 * time.Minute
+)
+
+type ClientClaimSummary struct {
+	ClientAddr     string `json:"client_addr"`
+	ClaimCount     int64  `json:"claim_count" bson:"claim_count"`
+	DistinctMiners int64  `json:"distinct_miners" bson:"distinct_miners"`
+	TotalBytes     int64  `json:"total_bytes" bson:"total_bytes"`
+	MinTermStart   int64  `json:"min_term_start" bson:"min_term_start"`
+	MaxTermStart   int64  `json:"max_term_start" bson:"max_term_start"`
+	ActiveClaims   int64  `json:"active_claims" bson:"active_claims"`
+	ExpiredClaims  int64  `json:"expired_claims" bson:"expired_claims"`
+	HeadEpoch      int64  `json:"head_epoch"`
+	ComputedAt     string `json:"computed_at"`
+}
+
+// handleClientPaths routes /clients/<client_addr>/...
+func handleClientPaths(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/clients/")
+	switch {
+	case strings.HasSuffix(rest, "/miners/best"):
+		handleClientBestMiners(w, r)
+	case strings.HasSuffix(rest, "/stats"):
+		handleClientStats(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// clientFromPath extracts <client_addr> from /clients/<client_addr><suffix>
+func clientFromPath(r *http.Request, suffix string) (string, bool) {
+	client, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/clients/"), suffix)
+	if !ok || client == "" || strings.Contains(client, "/") {
+		return "", false
+	}
+	return client, true
+}
+
+// GET /clients/<client_addr>/stats
+// Claims summary of one client (soft-deleted claims excluded). Claims are active while
+// term_start + term_max is after meta:chain:head_epoch. Cached for 10 minutes.
+func handleClientStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	client, ok := clientFromPath(r, "/stats")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	key := keyClientPrefix + client + keyClientSummarySuffix
+
+	cached, err := rds.Get(ctx, key).Result()
+	if err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(cached))
+		return
+	}
+	if !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	st, err := computeClientSummary(ctx, client)
+	if err != nil {
+		http.Error(w, "client stats error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if st.ClaimCount == 0 {
+		http.Error(w, "no claims for client", http.StatusNotFound)
+		return
+	}
+	if bz, err := json.Marshal(st); err == nil {
+		_ = rds.Set(ctx, key, string(bz), clientSummaryTTL).Err()
+	}
+	writeJSON(w, st)
+}
+
+// headEpoch reads the chain head published by integration/claims, falling back to the wall clock
+func headEpoch(ctx context.Context) int64 {
+	n, _ := readHeadEpoch(ctx)
+	return n
+}
+
+// readHeadEpoch is headEpoch plus whether the value came from the chain (false = wall-clock estimate)
+func readHeadEpoch(ctx context.Context) (int64, bool) {
+	if n, err := rds.Get(ctx, keyHeadEpoch).Int64(); err == nil {
+		return n, true
+	}
+	return claimstore.CurrentEpoch(time.Now()), false
+}
+
+func computeClientSummary(ctx context.Context, client string) (ClientClaimSummary, error) {
+	epoch := headEpoch(ctx)
+	cur, err := colClaims.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: activeClaims(clientClaimsFilter(client))}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            nil,
+			"claim_count":    bson.M{"$sum": 1},
+			"miners":         bson.M{"$addToSet": "$provider_id"},
+			"total_bytes":    bson.M{"$sum": "$size"},
+			"min_term_start": bson.M{"$min": "$term_start"},
+			"max_term_start": bson.M{"$max": "$term_start"},
+			"active_claims": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{bson.M{"$add": bson.A{"$term_start", "$term_max"}}, epoch}}, 1, 0,
+			}}},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"distinct_miners": bson.M{"$size": "$miners"}}}},
+		{{Key: "$project", Value: bson.M{"miners": 0}}},
+	})
+	if err != nil {
+		return ClientClaimSummary{}, err
+	}
+	var out []ClientClaimSummary
+	if err := cur.All(ctx, &out); err != nil {
+		return ClientClaimSummary{}, err
+	}
+
+	var st ClientClaimSummary
+	if len(out) > 0 {
+		st = out[0]
+	}
+	st.ClientAddr = client
+	st.ExpiredClaims = st.ClaimCount - st.ActiveClaims
+	st.HeadEpoch = epoch
+	st.ComputedAt = time.Now().UTC().Format(time.RFC3339)
+	return st, nil
+}
+
+// clientClaimsFilter matches claims by client_addr; ID addresses (f0123/t0123) also match client_id,
+// which is all the claims ingester stores
+func clientClaimsFilter(client string) bson.M {
+	if len(client) > 2 && (strings.HasPrefix(client, "f0") || strings.HasPrefix(client, "t0")) {
+		if id, err := strconv.ParseInt(client[2:], 10, 64); err == nil {
+			return bson.M{"$or": bson.A{bson.M{"client_addr": client}, bson.M{"client_id": id}}}
+		}
+	}
+	return bson.M{"client_addr": client}
+}
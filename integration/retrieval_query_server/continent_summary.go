@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyContinentSummary = "meta:continent:summary"
+	continentSummaryTTL = 30 * time.Minute
+	continentTopN       = 3
+)
+
+type ContinentMiner struct {
+	MinerID     string  `json:"miner_id"`
+	SuccessRate float64 `json:"success_rate"`
+}
+
+type ContinentSummary struct {
+	Continent       string           `json:"continent"`
+	MinerCount      int64            `json:"miner_count"`
+	MeanSuccessRate float64          `json:"mean_success_rate"` // sum of scores / miner_count
+	TopMiners       []ContinentMiner `json:"top_miners"`
+}
+
+// /miners/continent-summary
+// Miner count, mean HTTP success rate and top-3 miners of every per-continent ZSET, cached in
+// meta:continent:summary for 30 minutes.
+func handleMinersContinentSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if val, err := rds.Get(ctx, keyContinentSummary).Result(); err == nil {
+		var cached []ContinentSummary
+		if json.Unmarshal([]byte(val), &cached) == nil {
+			writeJSON(w, cached)
+			return
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out, err := computeContinentSummary(ctx)
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if bz, err := json.Marshal(out); err == nil {
+		_ = rds.Set(ctx, keyContinentSummary, string(bz), continentSummaryTTL).Err()
+	}
+	writeJSON(w, out)
+}
+
+// computeContinentSummary reads each continent ZSET with scores (highest first); the first entries
+// are the top miners and the scores give the mean
+func computeContinentSummary(ctx context.Context) ([]ContinentSummary, error) {
+	conts, err := rds.SMembers(ctx, setMinerConts).Result()
+	if err != nil {
+		return nil, err
+	}
+	pipe := rds.Pipeline()
+	cmds := make([]*redis.ZSliceCmd, len(conts))
+	for i, c := range conts {
+		cmds[i] = pipe.ZRevRangeWithScores(ctx, zsetMinerCont+c, 0, -1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	out := make([]ContinentSummary, 0, len(conts))
+	for i, c := range conts {
+		zs := cmds[i].Val()
+		if len(zs) == 0 {
+			continue
+		}
+		s := ContinentSummary{Continent: c, MinerCount: int64(len(zs)), TopMiners: []ContinentMiner{}}
+		var sum float64
+		for j, z := range zs {
+			sum += z.Score
+			if j < continentTopN {
+				id, _ := z.Member.(string)
+				s.TopMiners = append(s.TopMiners, ContinentMiner{MinerID: id, SuccessRate: z.Score})
+			}
+		}
+		s.MeanSuccessRate = sum / float64(s.MinerCount)
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].MinerCount != out[j].MinerCount {
+			return out[i].MinerCount > out[j].MinerCount
+		}
+		return out[i].Continent < out[j].Continent
+	})
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// /miners/count?protocol=http
+// Number of ranked miners (ZCARD of the protocol ZSET) and the time of the cron run that built it,
+// in one pipeline. Cacheable for a minute: the ZSET only changes when the cron runs.
+func handleMinersCount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	protocol, zset, ok := parseProtocol(r.URL.Query().Get("protocol"))
+	if !ok {
+		http.Error(w, "unsupported protocol", http.StatusBadRequest)
+		return
+	}
+
+	pipe := rds.Pipeline()
+	countCmd := pipe.ZCard(ctx, zset)
+	tsCmd := pipe.Get(ctx, keyLastCronRun)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	writeJSON(w, map[string]any{
+		"count":    countCmd.Val(),
+		"protocol": protocol,
+		"as_of":    tsCmd.Val(), // "" before the first cron run
+	})
+}
+
+// /clients/count
+// Number of clients in idx:clients:http (ZCARD, rebuilt by the cron) and the time of that cron run.
+// Like /miners/count this never touches MongoDB.
+func handleClientsCount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pipe := rds.Pipeline()
+	countCmd := pipe.ZCard(ctx, zsetClientHTTP)
+	tsCmd := pipe.Get(ctx, keyLastCronRun)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	writeJSON(w, map[string]any{
+		"count": countCmd.Val(),
+		"as_of": tsCmd.Val(),
+	})
+}
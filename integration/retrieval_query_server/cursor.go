@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// detailsCursor is the opaque position token of /details cursor pagination.
+// It points at the last row of the previous page (sort: created_at, _id; desc unless Asc).
+type detailsCursor struct {
+	ID        string `json:"id"`            // ObjectID hex
+	CreatedAt int64  `json:"t"`             // unix millis
+	PageSize  int    `json:"ps"`            // page size is fixed for the whole walk
+	Asc       bool   `json:"asc,omitempty"` // order_by=created_at_asc
+}
+
+var errBadCursor = errors.New("invalid cursor")
+
+// encodeCursor returns base64url(json) or, with CURSOR_HMAC_SECRET set, base64url(json).base64url(hmac)
+func encodeCursor(c detailsCursor) string {
+	bz, _ := json.Marshal(c)
+	tok := base64.RawURLEncoding.EncodeToString(bz)
+	if cfg.CursorSecret != "" {
+		tok += "." + base64.RawURLEncoding.EncodeToString(signCursor(tok))
+	}
+	return tok
+}
+
+func decodeCursor(tok string) (detailsCursor, error) {
+	var c detailsCursor
+	payload, sig, signed := strings.Cut(tok, ".")
+	if cfg.CursorSecret != "" {
+		if !signed {
+			return c, errBadCursor
+		}
+		got, err := base64.RawURLEncoding.DecodeString(sig)
+		if err != nil || !hmac.Equal(got, signCursor(payload)) {
+			return c, errBadCursor
+		}
+	}
+	bz, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return c, errBadCursor
+	}
+	if err := json.Unmarshal(bz, &c); err != nil {
+		return c, errBadCursor
+	}
+	if _, err := primitive.ObjectIDFromHex(c.ID); err != nil || c.PageSize <= 0 || c.PageSize > maxPageSize {
+		return c, errBadCursor
+	}
+	return c, nil
+}
+
+func signCursor(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(cfg.CursorSecret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// filter returns the "strictly after this row" condition for the (created_at, _id) sort
+func (c detailsCursor) filter() bson.M {
+	id, _ := primitive.ObjectIDFromHex(c.ID)
+	t := time.UnixMilli(c.CreatedAt)
+	op := "$lt"
+	if c.Asc {
+		op = "$gt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{op: t}},
+		bson.M{"created_at": t, "_id": bson.M{op: id}},
+	}}
+}
+
+// cursorAfter builds the cursor for the last row of a page
+func cursorAfter(id primitive.ObjectID, createdAt time.Time, pageSize int, asc bool) string {
+	return encodeCursor(detailsCursor{ID: id.Hex(), CreatedAt: createdAt.UnixMilli(), PageSize: pageSize, Asc: asc})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DataQuality struct {
+	TotalClaims        int64   `json:"total_claims" bson:"total_claims"`
+	EmptyClientAddr    int64   `json:"empty_client_addr" bson:"empty_client_addr"`
+	EmptyClientAddrPct float64 `json:"empty_client_addr_pct" bson:"-"`
+	EmptyMinerAddr     int64   `json:"empty_miner_addr" bson:"empty_miner_addr"`
+	EmptyMinerAddrPct  float64 `json:"empty_miner_addr_pct" bson:"-"`
+	EmptyDataCID       int64   `json:"empty_data_cid" bson:"empty_data_cid"`
+	EmptyDataCIDPct    float64 `json:"empty_data_cid_pct" bson:"-"`
+	ZeroProviderID     int64   `json:"zero_provider_id" bson:"zero_provider_id"`
+	ZeroProviderIDPct  float64 `json:"zero_provider_id_pct" bson:"-"`
+	ComputedAt         string  `json:"computed_at" bson:"-"`
+}
+
+// GET /admin/data-quality
+// Counts claims (soft-deleted excluded) with missing identifiers, e.g. a blank client_addr when the
+// ingester's address lookup failed. One $group pass over the claims collection, not cached.
+func handleAdminDataQuality(w http.ResponseWriter, r *http.Request) {
+	dq, err := computeDataQuality(r.Context())
+	if err != nil {
+		http.Error(w, "mongo aggregate error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, dq)
+}
+
+func computeDataQuality(ctx context.Context) (DataQuality, error) {
+	// missing and "" both count as empty
+	countIf := func(field string, empty any) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{field, empty}}, empty}}, 1, 0,
+		}}}
+	}
+	cur, err := colClaims.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: activeClaims(bson.M{})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":               nil,
+			"total_claims":      bson.M{"$sum": 1},
+			"empty_client_addr": countIf("$client_addr", ""),
+			"empty_miner_addr":  countIf("$miner_addr", ""),
+			"empty_data_cid":    countIf("$data_cid", ""),
+			"zero_provider_id":  countIf("$provider_id", 0),
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return DataQuality{}, err
+	}
+	defer cur.Close(ctx)
+
+	var out []DataQuality
+	if err := cur.All(ctx, &out); err != nil {
+		return DataQuality{}, err
+	}
+	var dq DataQuality
+	if len(out) > 0 {
+		dq = out[0]
+	}
+	pct := func(n int64) float64 {
+		if dq.TotalClaims == 0 {
+			return 0
+		}
+		return math.Round(float64(n)/float64(dq.TotalClaims)*10000) / 100
+	}
+	dq.EmptyClientAddrPct = pct(dq.EmptyClientAddr)
+	dq.EmptyMinerAddrPct = pct(dq.EmptyMinerAddr)
+	dq.EmptyDataCIDPct = pct(dq.EmptyDataCID)
+	dq.ZeroProviderIDPct = pct(dq.ZeroProviderID)
+	dq.ComputedAt = time.Now().UTC().Format(time.RFC3339)
+	return dq, nil
+}
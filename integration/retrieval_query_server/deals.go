@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// dealClaim is the claims document subset needed for a /deals response
+type dealClaim struct {
+	ClaimID    int64  `bson:"claim_id"`
+	ClientID   int64  `bson:"client_id"`
+	ClientAddr string `bson:"client_addr"`
+	MinerAddr  string `bson:"miner_addr"`
+	DataCID    string `bson:"data_cid"`
+	TermStart  int64  `bson:"term_start"`
+	TermMax    int64  `bson:"term_max"`
+}
+
+// client returns client_addr, or the ID address of client_id (the ingester stores only the ID) using
+// the network prefix of the miner address
+func (c dealClaim) client() string {
+	if c.ClientAddr != "" || c.ClientID == 0 {
+		return c.ClientAddr
+	}
+	prefix := "f"
+	if c.MinerAddr != "" {
+		prefix = c.MinerAddr[:1]
+	}
+	return prefix + "0" + strconv.FormatInt(c.ClientID, 10)
+}
+
+// /deals?deal_id=N
+// Legacy deal-shaped view of a claim: deal_id is matched against claim_id in the claims collection
+// (soft-deleted claims excluded). end_epoch is term_start + term_max.
+func handleDeals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	dealID, err := strconv.ParseInt(r.URL.Query().Get("deal_id"), 10, 64)
+	if err != nil || dealID <= 0 {
+		http.Error(w, "deal_id must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	var c dealClaim
+	err = colClaims.FindOne(ctx, activeClaims(bson.M{"claim_id": dealID})).Decode(&c)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "deal not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "mongo find error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"deal_id":     c.ClaimID,
+		"provider":    c.MinerAddr,
+		"client":      c.client(),
+		"data_cid":    c.DataCID,
+		"start_epoch": c.TermStart,
+		"end_epoch":   c.TermStart + c.TermMax,
+	})
+}
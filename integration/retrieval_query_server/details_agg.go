@@ -0,0 +1,199 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// parseDays parses a positive "days" query value (def when empty)
+func parseDays(s string, def int) (int, bool) {
+	if s == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// /details/by_miner?client_addr=...&retrieval_method=http&days=7
+// Live per-miner summary of one client's task results (no Redis cache), sorted by HTTP success rate desc.
+func handleDetailsByMiner(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	client := q.Get("client_addr")
+	if client == "" {
+		http.Error(w, "client_addr is required", http.StatusBadRequest)
+		return
+	}
+	method := q.Get("retrieval_method")
+	if method == "" {
+		method = "http"
+	}
+	if method != "http" {
+		http.Error(w, "only http supported", http.StatusBadRequest)
+		return
+	}
+	v := apiVersion(r)
+	days, ok := parseDays(q.Get("days"), 7)
+	if !ok {
+		http.Error(w, "days must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"task.module":          method,
+			"task.metadata.client": client,
+			"created_at":           bson.M{"$gte": time.Now().Add(-time.Duration(days) * 24 * time.Hour)},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$task.provider.id",
+			"total": bson.M{"$sum": 1},
+			"ok":    bson.M{"$sum": bson.M{"$cond": []any{"$result.success", 1, 0}}},
+			// ttfb/speed are only stored for successful retrievals; $avg skips missing values
+			"mean_ttfb":  bson.M{"$avg": "$result.ttfb"},
+			"mean_speed": bson.M{"$avg": "$result.speed"},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"rate": bson.M{"$divide": []any{"$ok", "$total"}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "rate", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cur, err := colResult.Aggregate(ctx, pipeline)
+	if err != nil {
+		http.Error(w, "mongo aggregate error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cur.Close(ctx)
+
+	type aggRow struct {
+		ID        string  `bson:"_id"`
+		Total     int64   `bson:"total"`
+		OK        int64   `bson:"ok"`
+		MeanTTFB  float64 `bson:"mean_ttfb"` // nanoseconds (time.Duration)
+		MeanSpeed float64 `bson:"mean_speed"`
+		Rate      float64 `bson:"rate"`
+	}
+	items := make([]map[string]any, 0)
+	for cur.Next(ctx) {
+		var a aggRow
+		if err := cur.Decode(&a); err != nil {
+			http.Error(w, "decode error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		items = append(items, map[string]any{
+			"miner_id":          a.ID,
+			"total":             a.Total,
+			"ok":                a.OK,
+			"success_rate_http": rateValue(v, a.Rate),
+			"mean_ttfb_ms":      a.MeanTTFB / float64(time.Millisecond),
+			"mean_speed_bps":    a.MeanSpeed,
+		})
+	}
+	if err := cur.Err(); err != nil {
+		http.Error(w, "cursor error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"client_addr": client,
+		"days":        days,
+		"count":       len(items),
+		"items":       items,
+	})
+}
+
+// /details/by_date?miner_addr=...&retrieval_method=http&days=30&granularity=day|hour
+// Time series of totals/successes bucketed by created_at (UTC). granularity=hour requires days <= 7.
+func handleDetailsByDate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	method := q.Get("retrieval_method")
+	if method == "" {
+		method = "http"
+	}
+	if method != "http" {
+		http.Error(w, "only http supported", http.StatusBadRequest)
+		return
+	}
+	days, ok := parseDays(q.Get("days"), 30)
+	if !ok {
+		http.Error(w, "days must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	var format string
+	switch g := q.Get("granularity"); g {
+	case "", "day":
+		format = "%Y-%m-%d"
+	case "hour":
+		if days > 7 {
+			http.Error(w, "granularity=hour is only allowed with days <= 7", http.StatusBadRequest)
+			return
+		}
+		format = "%Y-%m-%dT%H:00"
+	default:
+		http.Error(w, "granularity must be day or hour", http.StatusBadRequest)
+		return
+	}
+
+	match := bson.M{
+		"task.module": method,
+		"created_at":  bson.M{"$gte": time.Now().Add(-time.Duration(days) * 24 * time.Hour)},
+	}
+	// Without a miner the created_at range is the selective part of the match: hint the created_at index.
+	// With one, the planner picks (task.module, task.provider.id, created_at) on its own.
+	opts := options.Aggregate()
+	if miner := q.Get("miner_addr"); miner != "" {
+		match["task.provider.id"] = miner
+	} else {
+		opts.SetHint(bson.D{{Key: "created_at", Value: -1}})
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     bson.M{"$dateToString": bson.M{"format": format, "date": "$created_at", "timezone": "UTC"}},
+			"total":   bson.M{"$sum": 1},
+			"success": bson.M{"$sum": bson.M{"$cond": []any{"$result.success", 1, 0}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	cur, err := colResult.Aggregate(ctx, pipeline, opts)
+	if err != nil {
+		http.Error(w, "mongo aggregate error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cur.Close(ctx)
+
+	type bucket struct {
+		Date    string  `bson:"_id" json:"date"`
+		Total   int64   `bson:"total" json:"total"`
+		Success int64   `bson:"success" json:"success"`
+		Rate    float64 `bson:"-" json:"rate"`
+	}
+	items := make([]bucket, 0)
+	for cur.Next(ctx) {
+		var b bucket
+		if err := cur.Decode(&b); err != nil {
+			http.Error(w, "decode error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if b.Total > 0 {
+			b.Rate = float64(b.Success) / float64(b.Total)
+		}
+		items = append(items, b)
+	}
+	if err := cur.Err(); err != nil {
+		http.Error(w, "cursor error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, items)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const maxCIDResults = 1000 // newest results per CID; older attempts are dropped
+
+// /details/cid/<cid>?miner_addr=
+// Every retrieval result of one piece (task.content.cid), optionally for a single miner, newest first and
+// grouped by task.module: {"cid": "...", "results": {"http": [...], "graphsync": [...], "bitswap": [...]}}.
+func handleDetailsByCID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cid := strings.TrimPrefix(r.URL.Path, "/details/cid/")
+	if cid == "" || strings.Contains(cid, "/") {
+		http.Error(w, "cid is required", http.StatusBadRequest)
+		return
+	}
+	minerAddr := r.URL.Query().Get("miner_addr")
+
+	filter := bson.M{"task.content.cid": cid}
+	if minerAddr != "" {
+		filter["task.provider.id"] = minerAddr
+	}
+	sortBy := bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+
+	fctx, span := startSpan(ctx, "mongo.find "+colResult.Name(),
+		attribute.String("cid", cid), attribute.String("miner_addr", minerAddr))
+	var docs []TaskResultDoc
+	err := withBreaker(detailsBreaker, func() (err error) {
+		docs, err = findDetailsPage(fctx, filter, sortBy, 0, maxCIDResults)
+		return err
+	})
+	span.SetAttributes(attribute.Int("result_count", len(docs)))
+	endSpan(span, err)
+	if breakerRejected(err) {
+		writeBreakerOpen(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, "mongo find error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(docs) == 0 {
+		http.Error(w, "no results for cid", http.StatusNotFound)
+		return
+	}
+
+	// The three protocols are always present (possibly empty); other modules only when they have results
+	results := map[string][]DetailsRow{"http": {}, "graphsync": {}, "bitswap": {}}
+	for _, doc := range docs {
+		results[doc.Task.Module] = append(results[doc.Task.Module], detailsRow(doc))
+	}
+	writeJSON(w, map[string]any{
+		"cid":     cid,
+		"count":   len(docs),
+		"results": results,
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDetailsFilterCombinesMinerAndClient(t *testing.T) {
+	q := url.Values{}
+	q.Set("miner_addr", "f01234")
+	q.Set("client_addr", "f1abc")
+	q.Set("status", "0")
+
+	filter, err := detailsFilter(q)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"task.module":          "http",
+		"task.provider.id":     "f01234",
+		"task.metadata.client": "f1abc",
+		"result.success":       true,
+	}, filter)
+}
+
+func TestDetailsFilterSingleField(t *testing.T) {
+	filter, err := detailsFilter(url.Values{"client_addr": {"f1abc"}})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"task.module": "http", "task.metadata.client": "f1abc"}, filter)
+
+	filter, err = detailsFilter(url.Values{"miner_addr": {"f01234"}, "status": {"1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"task.module": "http", "task.provider.id": "f01234", "result.success": false}, filter)
+}
+
+func TestDetailsFilterErrorCode(t *testing.T) {
+	filter, err := detailsFilter(url.Values{"error_code": {"TIMEOUT"}})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"task.module": "http", "result.error_code": "TIMEOUT"}, filter)
+
+	filter, err = detailsFilter(url.Values{"error_code": {"TIMEOUT, CONNECTION_REFUSED"}})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"task.module":       "http",
+		"result.error_code": bson.M{"$in": []string{"TIMEOUT", "CONNECTION_REFUSED"}},
+	}, filter)
+}
+
+func TestDetailsFilterRejectsBadInput(t *testing.T) {
+	_, err := detailsFilter(url.Values{"retrieval_method": {"bitswap"}})
+	assert.Error(t, err)
+
+	_, err = detailsFilter(url.Values{"status": {"2"}})
+	assert.Error(t, err)
+}
+
+func TestDetailsRowCreationTime(t *testing.T) {
+	at := time.Date(2025, 9, 12, 10, 22, 33, 500e6, time.FixedZone("UTC+8", 8*3600))
+	assert.Equal(t, "2025-09-12T02:22:33Z", detailsRow(TaskResultDoc{CreatedAt: at}).CreationTime)
+	assert.Equal(t, "", detailsRow(TaskResultDoc{}).CreationTime)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const geoCacheTTL = time.Hour
+
+type GeoGroup struct {
+	Group           string  `json:"group"`
+	Count           int64   `json:"count"`
+	MeanSuccessRate float64 `json:"mean_success_rate"`
+}
+
+// /miners/geo?group_by=country|continent|region
+// Miner count and mean HTTP success rate per location group, cached in meta:geo:<group_by> for 1h.
+func handleMinersGeo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = "country"
+	}
+	var pick func(RateDoc) string
+	switch groupBy {
+	case "country":
+		pick = func(d RateDoc) string { return d.Country }
+	case "continent":
+		pick = func(d RateDoc) string { return d.Continent }
+	case "region":
+		pick = func(d RateDoc) string { return d.Region }
+	default:
+		http.Error(w, "group_by must be country, continent or region", http.StatusBadRequest)
+		return
+	}
+
+	cacheKey := keyGeoPrefix + groupBy
+	if val, err := rds.Get(ctx, cacheKey).Result(); err == nil {
+		var cached []GeoGroup
+		if json.Unmarshal([]byte(val), &cached) == nil {
+			writeJSON(w, cached)
+			return
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	groups, err := computeGeoGroups(ctx, pick)
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if bz, err := json.Marshal(groups); err == nil {
+		_ = rds.Set(ctx, cacheKey, string(bz), geoCacheTTL).Err()
+	}
+	writeJSON(w, groups)
+}
+
+// computeGeoGroups walks the miner ZSET in batches and aggregates the miner docs by pick(doc)
+func computeGeoGroups(ctx context.Context, pick func(RateDoc) string) ([]GeoGroup, error) {
+	const batch = 1000
+	type acc struct {
+		count int64
+		sum   float64
+	}
+	agg := make(map[string]*acc)
+
+	for start := int64(0); ; start += batch {
+		ids, err := rds.ZRange(ctx, zsetMinerHTTP, start, start+batch-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		pipe := rds.Pipeline()
+		cmds := make([]*redis.StringCmd, len(ids))
+		for i, id := range ids {
+			cmds[i] = pipe.Get(ctx, keyMinerPrefix+id)
+		}
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		for _, c := range cmds {
+			val, err := c.Result()
+			if err != nil {
+				continue
+			}
+			var rd RateDoc
+			if json.Unmarshal([]byte(val), &rd) != nil {
+				continue
+			}
+			g := pick(rd)
+			if g == "" {
+				g = "unknown"
+			}
+			a := agg[g]
+			if a == nil {
+				a = &acc{}
+				agg[g] = a
+			}
+			a.count++
+			a.sum += rd.SuccessRateHTTP
+		}
+		if len(ids) < batch {
+			break
+		}
+	}
+
+	out := make([]GeoGroup, 0, len(agg))
+	for g, a := range agg {
+		out = append(out, GeoGroup{Group: g, Count: a.count, MeanSuccessRate: a.sum / float64(a.count)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Group < out[j].Group
+	})
+	return out, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const maxLeaderboardN = 100
+
+// Ranking ZSET per protocol (only HTTP is aggregated today)
+var protocolZSets = map[string]string{
+	"http": zsetMinerHTTP,
+}
+
+// /miners/leaderboard?n=10&protocol=http&include_details=false
+// Top N miners of the protocol ZSET with rank and score. Without include_details this is a
+// single Redis round-trip (ZREVRANGE WITHSCORES + GET meta:last_cron_run in one pipeline).
+func handleMinersLeaderboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	protocol, zset, ok := parseProtocol(q.Get("protocol"))
+	if !ok {
+		http.Error(w, "unsupported protocol", http.StatusBadRequest)
+		return
+	}
+	n := parseTopN(q.Get("n"))
+
+	zctx, span := startSpan(ctx, "redis.zrevrange "+zset, attribute.Int("n", n))
+	pipe := rds.Pipeline()
+	zCmd := pipe.ZRevRangeWithScores(zctx, zset, 0, int64(n-1))
+	tsCmd := pipe.Get(zctx, keyLastCronRun)
+	if _, err := pipe.Exec(zctx); err != nil && !errors.Is(err, redis.Nil) {
+		endSpan(span, err)
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	span.SetAttributes(attribute.Int("result_count", len(zCmd.Val())))
+	span.End()
+
+	items, err := rankedItems(ctx, zCmd.Val(), q.Get("include_details") == "true", apiVersion(r))
+	if err != nil {
+		http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSONStream(w, map[string]any{
+		"protocol":    protocol,
+		"computed_at": tsCmd.Val(),
+	}, items)
+}
+
+// /miners/worst?n=10&protocol=http&min_checks=100&include_rate_zero=false&include_details=false
+// Bottom N miners (ascending score). Miners with fewer than min_checks checks are skipped, and so are
+// miners with a score of exactly 0 unless include_rate_zero=true (they may just have no data yet).
+func handleMinersWorst(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	protocol, zset, ok := parseProtocol(q.Get("protocol"))
+	if !ok {
+		http.Error(w, "unsupported protocol", http.StatusBadRequest)
+		return
+	}
+	n := parseTopN(q.Get("n"))
+	var minChecks int64
+	if v := q.Get("min_checks"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "min_checks must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		minChecks = n
+	}
+	minScore := "(0"
+	if q.Get("include_rate_zero") == "true" {
+		minScore = "-inf"
+	}
+
+	const batch = 200
+	var picked []redis.Z
+	for offset := int64(0); len(picked) < n; offset += batch {
+		zs, err := rds.ZRangeArgsWithScores(ctx, redis.ZRangeArgs{
+			Key: zset, Start: minScore, Stop: "+inf", ByScore: true, Offset: offset, Count: batch,
+		}).Result()
+		if err != nil {
+			http.Error(w, "redis zset error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if minChecks <= 0 {
+			picked = append(picked, zs...)
+		} else {
+			kept, err := filterMinChecks(ctx, zs, minChecks)
+			if err != nil {
+				http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			picked = append(picked, kept...)
+		}
+		if len(zs) < batch {
+			break
+		}
+	}
+	if len(picked) > n {
+		picked = picked[:n]
+	}
+
+	items, err := rankedItems(ctx, picked, q.Get("include_details") == "true", apiVersion(r))
+	if err != nil {
+		http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	computedAt, _ := rds.Get(ctx, keyLastCronRun).Result()
+	writeJSONStream(w, map[string]any{
+		"protocol":    protocol,
+		"computed_at": computedAt,
+	}, items)
+}
+
+// filterMinChecks keeps the entries whose miner doc reports at least minChecks HTTP checks
+func filterMinChecks(ctx context.Context, zs []redis.Z, minChecks int64) ([]redis.Z, error) {
+	if len(zs) == 0 {
+		return nil, nil
+	}
+	pipe := rds.Pipeline()
+	cmds := make([]*redis.StringCmd, len(zs))
+	for i, z := range zs {
+		cmds[i] = pipe.Get(ctx, keyMinerPrefix+z.Member.(string))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	out := make([]redis.Z, 0, len(zs))
+	for i, z := range zs {
+		val, err := cmds[i].Result()
+		if err != nil {
+			continue
+		}
+		var rd RateDoc
+		if json.Unmarshal([]byte(val), &rd) == nil && rd.TotalHTTP >= minChecks {
+			out = append(out, z)
+		}
+	}
+	return out, nil
+}
+
+// parseProtocol maps the protocol query value (default http) to its ranking ZSET
+func parseProtocol(p string) (string, string, bool) {
+	if p == "" {
+		p = "http"
+	}
+	zset, ok := protocolZSets[p]
+	return p, zset, ok
+}
+
+// parseTopN parses n (default 10, capped at 100)
+func parseTopN(s string) int {
+	n := 10
+	if v, err := strconv.Atoi(s); err == nil && v > 0 {
+		n = v
+	}
+	if n > maxLeaderboardN {
+		n = maxLeaderboardN
+	}
+	return n
+}
+
+// rankedItems turns ZSET entries into {rank, miner_id, score} items, optionally merged with the miner doc
+func rankedItems(ctx context.Context, zs []redis.Z, withDetails bool, v int) ([]map[string]any, error) {
+	var docs []*redis.StringCmd
+	if withDetails && len(zs) > 0 {
+		pipe := rds.Pipeline()
+		docs = make([]*redis.StringCmd, len(zs))
+		for i, z := range zs {
+			docs[i] = pipe.Get(ctx, keyMinerPrefix+z.Member.(string))
+		}
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+	}
+
+	items := make([]map[string]any, 0, len(zs))
+	for i, z := range zs {
+		id, _ := z.Member.(string)
+		it := map[string]any{
+			"rank":     i + 1,
+			"miner_id": id,
+			"score":    z.Score,
+		}
+		if docs != nil {
+			var rd RateDoc
+			if val, err := docs[i].Result(); err == nil {
+				_ = json.Unmarshal([]byte(val), &rd)
+				for k, val := range minerItem(id, rd, v) {
+					it[k] = val
+				}
+			}
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
@@ -6,50 +6,115 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+
+	"storagestats/pkg/env"
 )
 
 type Config struct {
-	MongoURI  string
-	MongoDB   string
-	RedisAddr string
-	RedisDB   int
-	BindAddr  string
+	MongoURI        string
+	MongoDB         string
+	RedisAddr       string
+	RedisDB         int
+	BindAddr        string
+	SSEMaxClients   int
+	CORSOrigins     []string // empty = allow any origin ("*")
+	CORSMaxAge      int      // CORS_MAX_AGE_SEC: Access-Control-Max-Age of preflight responses (0 = header not sent)
+	CursorSecret    string   // HMAC key for /details cursors (optional)
+	MongoWC         string   // MONGO_WRITE_CONCERN: "" (driver default), "1", "majority"
+	MongoReadPref   string   // MONGO_READ_PREFERENCE: "" (driver default), primary, secondaryPreferred, ...
+	ResultColl      string   // MONGO_RESULT_COLL: retrieval results collection in MongoDB
+	ClaimsColl      string   // MONGO_CLAIMS_COLL: claims collection in MongoDB (same db)
+	AggTimeoutMin   int      // MONGO_AGGREGATION_TIMEOUT_MIN: overall deadline of one cron run
+	AggMaxTimeMS    int      // MONGO_AGGREGATION_CURSOR_TIMEOUT_MS: server-side maxTimeMS per aggregation (0 = none)
+	PubSubEnabled   bool     // REDIS_PUBSUB_ENABLED: publish events:cron:complete after each successful run
+	OTELEndpoint    string   // OTEL_EXPORTER_OTLP_ENDPOINT: OTLP/HTTP collector; empty = no-op tracer
+	StatsPeriodMin  int      // STATS_PERIOD_MIN: minutes between cron runs (default 1440, min 5)
+	PipelineBatch   int      // REDIS_PIPELINE_BATCH_SIZE: cron write-back flushes the pipeline every N commands
+	AdminAPIKey     string   // ADMIN_API_KEY: bearer token of the /admin/* endpoints; empty = admin API disabled
+	MinSampleChecks int64    // MIN_SAMPLE_CHECKS: below this many checks a recommendation has confidence "low"
+	TLSDomains      []string // TLS_DOMAIN: comma-separated hostnames for autocert (Let's Encrypt); empty = no autocert
+	TLSCacheDir     string   // TLS_CACHE_DIR: where autocert keeps its account key and certificates
+	TLSCertFile     string   // TLS_CERT_FILE / TLS_KEY_FILE: static certificate, used when TLS_DOMAIN is empty
+	TLSKeyFile      string
+	RedisRetries    int          // REDIS_WRITE_RETRIES: attempts of a cron pipeline flush (and the startup ping), 1 = no retry
+	RedisBackoffMS  int          // REDIS_WRITE_BACKOFF_MS: wait before the first retry, doubled after each failure
+	ChangeStream    bool         // CHANGE_STREAM_ENABLED: refresh miners with new results every minute (needs a replica set)
+	WarmWorkers     int          // WARM_CACHE_WORKERS: miners re-aggregated concurrently by /admin/warm-cache
+	Weights         WeightConfig // COMPOSITE_WEIGHT_*: weights of the composite score, validated to sum to 1
 }
 
 var (
 	cfg       Config
 	mgo       *mongo.Client
 	db        *mongo.Database
-	colResult *mongo.Collection // Mongo collection: claims_task_result
+	colResult *mongo.Collection // Mongo collection: MONGO_RESULT_COLL (default claims_task_result)
+	colClaims *mongo.Collection // Mongo collection: claims (written by integration/claims)
 	rds       *redis.Client
 )
 
 const (
-	redisTTL        = 24 * time.Hour
-	statsPeriod     = 24 * time.Hour
-	defaultBind     = ":8787"
-	zsetMinerHTTP   = "idx:miners:http" // score = HTTP success rate
-	keyMinerPrefix  = "stats:miner:"    // stats:miner:<miner_id>
-	keyClientPrefix = "stats:client:"   // stats:client:<client_addr> (value = JSON array of items)
-	defaultPageSize = 15
-	maxPageSize     = 200
+	redisTTL          = 24 * time.Hour
+	minStatsPeriod    = 5 // minutes; shorter periods would keep the cron running back to back
+	defaultBind       = ":8787"
+	zsetMinerHTTP     = "idx:miners:http"             // score = HTTP success rate
+	zsetMinerCont     = "idx:miners:http:continent:"  // idx:miners:http:continent:<continent>, same scores as idx:miners:http
+	zsetMinerTested   = "idx:miners:last_tested"      // score = unix time of the newest HTTP check (last_checked_at)
+	setMinerConts     = "idx:miners:http:continents"  // continents that currently have a ZSET (for the rebuild)
+	tmpMinerConts     = "tmp:miners:http:continents:" // tmp:miners:http:continents:<AS,EU> (ZUNIONSTORE of several continents)
+	tmpMinerContsTTL  = time.Minute
+	keyMinerPrefix    = "stats:miner:"          // stats:miner:<miner_id>
+	minerSnapshotTTL  = 90 * 24 * time.Hour     // retention of the daily stats:miner:<miner_id>:<YYYYMMDD> copies
+	keyClientPrefix   = "stats:client:"         // stats:client:<client_addr> (value = JSON array of items)
+	zsetMinerClients  = "idx:miner:clients:"    // idx:miner:clients:<miner_id> (member = client, score = checks)
+	keyProviderPrefix = "stats:provider:"       // stats:provider:<miner_id> (ProviderDoc)
+	keyLastCronRun    = "meta:last_cron_run"    // RFC3339 time of the last finished cron run
+	keyHeadEpoch      = "meta:chain:head_epoch" // Lotus chain head height, written by integration/claims (2h TTL)
+	keyGeoPrefix      = "meta:geo:"             // meta:geo:<group_by> (cached /miners/geo result)
+	keyProviderJoin   = "cache:provider:"       // cache:provider:<miner_id> (cached /providers result)
+	chanCronComplete  = "events:cron:complete"  // Pub/Sub channel, see cronCompleteEvent
+	defaultPageSize   = 15
+	maxPageSize       = 200
 )
 
 type RateDoc struct {
-	SuccessRateHTTP      float64 `json:"success_rate_http"`
-	SuccessRateGraphsync float64 `json:"success_rate_graphsync"`
-	SuccessRateBitswap   float64 `json:"success_rate_bitswap"`
+	SuccessRateHTTP      float64    `json:"success_rate_http"`
+	SuccessRateGraphsync float64    `json:"success_rate_graphsync"`
+	SuccessRateBitswap   float64    `json:"success_rate_bitswap"`
+	TotalHTTP            int64      `json:"total_http"` // number of HTTP checks behind SuccessRateHTTP
+	City                 string     `json:"city,omitempty"`
+	Region               string     `json:"region,omitempty"`
+	Country              string     `json:"country,omitempty"`
+	Continent            string     `json:"continent,omitempty"`
+	ASN                  string     `json:"asn,omitempty"` // most common task.provider.asn of the miner's checks
+	ISP                  string     `json:"isp,omitempty"`
+	LastCheckedAt        string     `json:"last_checked_at,omitempty"` // RFC3339 time of the newest HTTP check
+	LastSuccessAt        *time.Time `json:"last_success_at,omitempty"` // newest successful HTTP check; nil = never
+}
+
+// ProviderDoc holds what the results tell about a miner's endpoint, kept apart from its RateDoc
+type ProviderDoc struct {
+	Multiaddrs []string `json:"multiaddrs"`
+	ObservedAt string   `json:"observed_at"` // RFC3339 created_at of the check that reported them
 }
 
 // Client statistics item (one entry per miner under a client)
@@ -59,6 +124,14 @@ type ClientMinerItem struct {
 	SuccessRateHTTP      float64 `json:"success_rate_http"`
 	SuccessRateGraphsync float64 `json:"success_rate_graphsync"`
 	SuccessRateBitswap   float64 `json:"success_rate_bitswap"`
+	TotalChecks          int64   `json:"total_checks"` // HTTP checks of this client/miner pair
+	CompositeScore       float64 `json:"composite_score"`
+}
+
+// compositeScore is the weighted mean of the three protocol success rates (0..1), weighted by cfg.Weights
+func compositeScore(httpRate, graphsyncRate, bitswapRate float64) float64 {
+	w := cfg.Weights
+	return w.HTTP*httpRate + w.GraphSync*graphsyncRate + w.Bitswap*bitswapRate
 }
 
 type aggOut2Keys struct {
@@ -71,25 +144,151 @@ type aggOut2Keys struct {
 }
 
 type aggOut1Key struct {
-	ID    string `bson:"_id"`
-	Total int64  `bson:"total"`
-	OK    int64  `bson:"ok"`
+	ID        string    `bson:"_id"`
+	Total     int64     `bson:"total"`
+	OK        int64     `bson:"ok"`
+	City      string    `bson:"city"`
+	Region    string    `bson:"region"`
+	Country   string    `bson:"country"`
+	Continent string    `bson:"continent"`
+	ASN       string    `bson:"asn"`
+	ISP       string    `bson:"isp"`
+	LastAt    time.Time `bson:"last_at"`
+	LastOKAt  time.Time `bson:"last_ok_at"` // zero if the miner never had a successful check
+	// Multiaddrs of the newest check that reported any; nil if none did
+	LastMaddrs *struct {
+		At    time.Time `bson:"at"`
+		Addrs []string  `bson:"addrs"`
+	} `bson:"last_maddrs"`
+}
+
+// TaskResultDoc mirrors a claims_task_result document (written by the retrieval workers, see pkg/task).
+// Only the fields read by /details are declared.
+type TaskResultDoc struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	Task struct {
+		Module   string `bson:"module"`
+		Provider struct {
+			ID string `bson:"id"`
+		} `bson:"provider"`
+		Content struct {
+			CID string `bson:"cid"`
+		} `bson:"content"`
+		Metadata map[string]string `bson:"metadata"`
+	} `bson:"task"`
+	Result struct {
+		Success      bool          `bson:"success"`
+		ErrorCode    string        `bson:"error_code"`
+		ErrorMessage string        `bson:"error_message"`
+		TTFB         time.Duration `bson:"ttfb"`
+		Speed        float64       `bson:"speed"` // bytes per second
+		Duration     time.Duration `bson:"duration"`
+		Downloaded   int64         `bson:"downloaded"`
+	} `bson:"result"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// DetailsRow is one task result as returned by /details and /details/cid/<cid>
+type DetailsRow struct {
+	MinerID         string  `json:"miner_id"`
+	CID             string  `json:"cid"`
+	Status          bool    `json:"status"`
+	ReturnCode      string  `json:"return_code"`
+	ResponseMessage string  `json:"response_message"`
+	DurationMs      float64 `json:"duration_ms"`
+	TTFB            float64 `json:"ttfb_ms"`
+	SpeedBps        float64 `json:"speed_bps"`
+	DownloadedBytes int64   `json:"downloaded_bytes"`
+	CreationTime    string  `json:"creation_time"` // RFC3339 (UTC), "" if unknown
+}
+
+func detailsRow(doc TaskResultDoc) DetailsRow {
+	row := DetailsRow{
+		MinerID:         doc.Task.Provider.ID,
+		CID:             doc.Task.Content.CID,
+		Status:          doc.Result.Success,
+		ReturnCode:      doc.Result.ErrorCode,
+		ResponseMessage: doc.Result.ErrorMessage,
+		DurationMs:      float64(doc.Result.Duration) / float64(time.Millisecond),
+		TTFB:            float64(doc.Result.TTFB) / float64(time.Millisecond),
+		SpeedBps:        doc.Result.Speed,
+		DownloadedBytes: doc.Result.Downloaded,
+	}
+	if !doc.CreatedAt.IsZero() {
+		row.CreationTime = doc.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return row
 }
 
 func mustInit() {
 	cfg = Config{
-		MongoURI:  getenv("MONGO_URI", "mongodb://127.0.0.1:27017"),
-		MongoDB:   getenv("MONGO_DB", "fil"),
-		RedisAddr: getenv("REDIS_ADDR", "127.0.0.1:6379"),
-		RedisDB:   mustAtoi(getenv("REDIS_DB", "0")),
-		BindAddr:  getenv("BIND_ADDR", defaultBind),
+		MongoURI:        getenv("MONGO_URI", "mongodb://127.0.0.1:27017"),
+		MongoDB:         getenv("MONGO_DB", "fil"),
+		RedisAddr:       getenv("REDIS_ADDR", "127.0.0.1:6379"),
+		RedisDB:         mustAtoi(getenv("REDIS_DB", "0")),
+		BindAddr:        getenv("BIND_ADDR", defaultBind),
+		SSEMaxClients:   mustAtoi(getenv("SSE_MAX_CLIENTS", "50")),
+		CORSOrigins:     splitList(getenv("CORS_ALLOWED_ORIGINS", "")),
+		CORSMaxAge:      mustAtoi(getenv("CORS_MAX_AGE_SEC", "86400")),
+		CursorSecret:    os.Getenv("CURSOR_HMAC_SECRET"),
+		MongoWC:         os.Getenv("MONGO_WRITE_CONCERN"),
+		MongoReadPref:   os.Getenv("MONGO_READ_PREFERENCE"),
+		ResultColl:      getenv("MONGO_RESULT_COLL", "claims_task_result"),
+		ClaimsColl:      getenv("MONGO_CLAIMS_COLL", "claims"),
+		AggTimeoutMin:   mustAtoi(getenv("MONGO_AGGREGATION_TIMEOUT_MIN", "10")),
+		AggMaxTimeMS:    mustAtoi(getenv("MONGO_AGGREGATION_CURSOR_TIMEOUT_MS", "0")),
+		PubSubEnabled:   getenv("REDIS_PUBSUB_ENABLED", "false") == "true",
+		OTELEndpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		PipelineBatch:   mustAtoi(getenv("REDIS_PIPELINE_BATCH_SIZE", "1000")),
+		AdminAPIKey:     os.Getenv("ADMIN_API_KEY"),
+		MinSampleChecks: int64(mustAtoi(getenv("MIN_SAMPLE_CHECKS", "50"))),
+		TLSDomains:      splitList(getenv("TLS_DOMAIN", "")),
+		TLSCacheDir:     getenv("TLS_CACHE_DIR", "autocert-cache"),
+		TLSCertFile:     os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:      os.Getenv("TLS_KEY_FILE"),
+		RedisRetries:    mustAtoi(getenv("REDIS_WRITE_RETRIES", "3")),
+		RedisBackoffMS:  mustAtoi(getenv("REDIS_WRITE_BACKOFF_MS", "500")),
+		ChangeStream:    getenv("CHANGE_STREAM_ENABLED", "false") == "true",
+		WarmWorkers:     mustAtoi(getenv("WARM_CACHE_WORKERS", "5")),
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		log.Fatalf("config: TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.RedisRetries < 1 {
+		log.Fatalf("config: REDIS_WRITE_RETRIES must be at least 1, got %d", cfg.RedisRetries)
+	}
+	if cfg.CORSMaxAge < 0 {
+		log.Fatalf("config: CORS_MAX_AGE_SEC must not be negative, got %d", cfg.CORSMaxAge)
+	}
+	if cfg.WarmWorkers < 1 {
+		log.Fatalf("config: WARM_CACHE_WORKERS must be at least 1, got %d", cfg.WarmWorkers)
+	}
+	if cfg.PipelineBatch <= 0 {
+		log.Fatalf("config: REDIS_PIPELINE_BATCH_SIZE must be positive, got %d", cfg.PipelineBatch)
+	}
+	weights, err := parseWeights(getenv("COMPOSITE_WEIGHT_HTTP", "0.6"),
+		getenv("COMPOSITE_WEIGHT_GRAPHSYNC", "0.2"), getenv("COMPOSITE_WEIGHT_BITSWAP", "0.2"))
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	cfg.Weights = weights
+	period, err := parseStatsPeriod(getenv("STATS_PERIOD_MIN", "1440"))
+	if err != nil {
+		log.Fatalf("config: %v", err)
 	}
+	cfg.StatsPeriodMin = period
+	log.Printf("stats period: %s", time.Duration(cfg.StatsPeriodMin)*time.Minute)
 
-	var err error
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	mgo, err = mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+	clientOpts := options.Client().ApplyURI(cfg.MongoURI)
+	if err := env.ApplyMongoConsistency(clientOpts, cfg.MongoWC, cfg.MongoReadPref); err != nil {
+		log.Fatalf("mongo config: %v", err)
+	}
+	log.Printf("mongo write_concern=%s read_preference=%s", orDefault(cfg.MongoWC), orDefault(cfg.MongoReadPref))
+
+	mgo, err = mongo.Connect(ctx, clientOpts)
 	if err != nil {
 		log.Fatalf("mongo connect: %v", err)
 	}
@@ -97,49 +296,280 @@ func mustInit() {
 		log.Fatalf("mongo ping: %v", err)
 	}
 	db = mgo.Database(cfg.MongoDB)
-	colResult = db.Collection("claims_task_result")
+	colResult = db.Collection(cfg.ResultColl)
+	colClaims = db.Collection(cfg.ClaimsColl)
+	ensureIndexes(ctx)
+	initTracing(ctx)
 
 	rds = redis.NewClient(&redis.Options{Addr: cfg.RedisAddr, DB: cfg.RedisDB})
-	if err := rds.Ping(context.Background()).Err(); err != nil {
+	pingCtx := context.Background()
+	if err := retryRedis(pingCtx, "ping", func() error { return rds.Ping(pingCtx).Err() }); err != nil {
 		log.Fatalf("redis ping: %v", err)
 	}
-	log.Printf("init ok. mongo=%s db=%s redis=%s bind=%s", cfg.MongoURI, cfg.MongoDB, cfg.RedisAddr, cfg.BindAddr)
+	log.Printf("init ok. mongo=%s db=%s redis=%s bind=%s cors=%v", env.MaskURI(cfg.MongoURI), cfg.MongoDB, env.MaskURI(cfg.RedisAddr), cfg.BindAddr, cfg.CORSOrigins)
+}
+
+// ensureIndexes creates the indexes the handlers rely on (no-op if they already exist)
+func ensureIndexes(ctx context.Context) {
+	_, err := colResult.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}}, // /details sort, /details/by_date hint
+		// /details with miner_addr and/or client_addr, sorted by created_at/_id
+		{Keys: bson.D{
+			{Key: "task.module", Value: 1},
+			{Key: "task.provider.id", Value: 1},
+			{Key: "task.metadata.client", Value: 1},
+			{Key: "created_at", Value: -1},
+			{Key: "_id", Value: -1},
+		}},
+		// /details?miner_addr=... without client_addr: the index above cannot serve the created_at sort
+		// when task.metadata.client is not pinned
+		{Keys: bson.D{
+			{Key: "task.module", Value: 1},
+			{Key: "task.provider.id", Value: 1},
+			{Key: "created_at", Value: -1},
+		}},
+		// /details/cid/<cid>, newest first
+		{Keys: bson.D{
+			{Key: "task.content.cid", Value: 1},
+			{Key: "created_at", Value: -1},
+		}},
+		// miner lookups outside the module filter, newest first (/providers/<miner_addr>/peerid)
+		{Keys: bson.D{
+			{Key: "task.provider.id", Value: 1},
+			{Key: "created_at", Value: -1},
+		}},
+		{Keys: bson.D{{Key: "task.metadata.client", Value: 1}}}, // client lookups
+		// /details?error_code=...
+		{Keys: bson.D{
+			{Key: "task.module", Value: 1},
+			{Key: "result.error_code", Value: 1},
+			{Key: "created_at", Value: -1},
+		}},
+	})
+	if err != nil {
+		log.Printf("create indexes: %v", err)
+	}
 }
 
 func startCron() {
 	go func() {
-		runOnce()
-		ticker := time.NewTicker(statsPeriod)
+		scheduledRun()
+		ticker := time.NewTicker(time.Duration(cfg.StatsPeriodMin) * time.Minute)
 		defer ticker.Stop()
 		for range ticker.C {
-			runOnce()
+			scheduledRun()
 		}
 	}()
 }
 
-func runOnce() {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+func scheduledRun() {
+	if _, err := runOnce(false); errors.Is(err, errCronRunning) {
+		log.Printf("[cron] previous run still in progress, skipping")
+	} else if err != nil {
+		log.Printf("[cron] run failed: %v", err)
+	}
+}
+
+// cronRunning counts the runs in progress; scheduled runs are skipped while it is non-zero
+var cronRunning atomic.Int32
+
+var errCronRunning = errors.New("cron run already in progress")
+
+// CronStats summarises one runOnce
+type CronStats struct {
+	MinersWritten  int
+	ClientsWritten int
+	Duration       time.Duration
+}
+
+// runOnce returns an error only if both aggregations failed; a single failure is logged and the
+// other aggregation's results are still stored. Unless force is set, it returns errCronRunning
+// when another run is in progress.
+func runOnce(force bool) (st CronStats, err error) {
+	if n := cronRunning.Add(1); n > 1 && !force {
+		cronRunning.Add(-1)
+		return st, errCronRunning
+	}
+	defer cronRunning.Add(-1)
+
+	start := time.Now()
+	defer func() { st.Duration = time.Since(start) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.AggTimeoutMin)*time.Minute)
 	defer cancel()
 
-	// 1) client_addr + miner_addr statistics (store list into key: stats:client:<client_addr>)
-	if err := computeAndStoreClientMiner(ctx); err != nil {
-		log.Printf("[cron] client+miner agg error: %v", err)
-	} else {
-		log.Println("[cron] client+miner agg ok")
+	// 0) remember the scores webhook subscribers care about (the miner ZSET is rebuilt below)
+	subs, err := loadWebhooks(ctx)
+	if err != nil {
+		log.Printf("[cron] load webhooks error: %v", err)
+	}
+	var before map[string]float64
+	if len(subs) > 0 {
+		if before, err = webhookScores(ctx, subs); err != nil {
+			log.Printf("[cron] webhook scores error: %v", err)
+			subs = nil
+		}
 	}
 
+	// Both aggregations run concurrently. A plain errgroup.Group (no shared cancellation) so that
+	// one failing does not abort the other: each flushes its own results to Redis.
+	var (
+		g                   errgroup.Group
+		clients, miners     int
+		clientErr, minerErr error
+	)
+	// 1) client_addr + miner_addr statistics (store list into key: stats:client:<client_addr>)
+	g.Go(func() error {
+		clients, clientErr = computeAndStoreClientMiner(ctx)
+		return clientErr
+	})
 	// 2) miner_addr statistics (store object into key: stats:miner:<miner>, and update ZSET)
-	if err := computeAndStoreMiner(ctx); err != nil {
-		log.Printf("[cron] miner agg error: %v", err)
+	g.Go(func() error {
+		miners, minerErr = computeAndStoreMiner(ctx)
+		return minerErr
+	})
+	// 2.1) claimed bytes per miner (vol:miner:<miner>); best effort, never fails the run
+	g.Go(func() error {
+		if n, err := computeAndStoreMinerVolume(ctx); err != nil {
+			log.Printf("[cron] miner volume agg error: %v", err)
+		} else {
+			log.Printf("[cron] miner volume agg ok (%d miners)", n)
+		}
+		return nil
+	})
+	_ = g.Wait() // both errors are inspected below
+
+	if clientErr != nil {
+		log.Printf("[cron] client+miner agg error: %v", clientErr)
 	} else {
-		log.Println("[cron] miner agg ok")
+		log.Printf("[cron] client+miner agg ok (%d clients)", clients)
+	}
+	if minerErr != nil {
+		log.Printf("[cron] miner agg error: %v", minerErr)
+	} else {
+		log.Printf("[cron] miner agg ok (%d miners)", miners)
+	}
+	ok := clientErr == nil && minerErr == nil
+	st.MinersWritten, st.ClientsWritten = miners, clients
+
+	runAt := time.Now().UTC()
+	if err := rds.Set(ctx, keyLastCronRun, runAt.Format(time.RFC3339), 0).Err(); err != nil {
+		log.Printf("[cron] set %s error: %v", keyLastCronRun, err)
+	}
+
+	// 3) push the fresh ranking to /miners/stream subscribers
+	if err := broadcastTopMiners(ctx); err != nil {
+		log.Printf("[cron] sse broadcast error: %v", err)
+	}
+
+	// 4) tell downstream services that fresh stats are available
+	if ok && cfg.PubSubEnabled {
+		go publishCronComplete(runAt, miners, clients)
+	}
+
+	// 5) webhook subscribers: miners whose rate moved by more than min_rate_change
+	if ok && len(subs) > 0 {
+		go notifyWebhooks(subs, before, runAt)
+	}
+
+	if clientErr != nil && minerErr != nil {
+		return st, errors.Join(clientErr, minerErr)
+	}
+	return st, nil
+}
+
+// cronCompleteEvent is published on events:cron:complete after every successful cron run:
+//
+//	{"run_at": "2025-09-10T00:00:00Z", "miners_updated": 1234, "clients_updated": 567}
+//
+// run_at is the UTC completion time (same value as meta:last_cron_run).
+type cronCompleteEvent struct {
+	RunAt          string `json:"run_at"`
+	MinersUpdated  int    `json:"miners_updated"`
+	ClientsUpdated int    `json:"clients_updated"`
+}
+
+// publishCronComplete is fire-and-forget: it gets 1s and only logs failures
+func publishCronComplete(runAt time.Time, miners, clients int) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	bz, _ := json.Marshal(cronCompleteEvent{
+		RunAt:          runAt.Format(time.RFC3339),
+		MinersUpdated:  miners,
+		ClientsUpdated: clients,
+	})
+	if err := rds.Publish(ctx, chanCronComplete, bz).Err(); err != nil {
+		log.Printf("[cron] publish %s error: %v", chanCronComplete, err)
 	}
 }
 
 // ============= Aggregations =============
 
+// batchPipe is a Redis pipeline that is flushed every cfg.PipelineBatch queued commands, so a large
+// write-back never sends one huge pipeline that blocks the connection
+type batchPipe struct {
+	redis.Pipeliner
+	size int
+}
+
+func newBatchPipe() *batchPipe {
+	return &batchPipe{Pipeliner: rds.Pipeline(), size: cfg.PipelineBatch}
+}
+
+// maybeFlush executes the pipeline once it holds at least size commands
+func (p *batchPipe) maybeFlush(ctx context.Context) error {
+	if p.Len() < p.size {
+		return nil
+	}
+	return p.flush(ctx)
+}
+
+func (p *batchPipe) flush(ctx context.Context) error {
+	if p.Len() == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// A failed batch is replayed as a whole; the write-back only queues idempotent commands in order
+	var cmds []redis.Cmder
+	return retryRedis(ctx, "pipeline exec", func() (err error) {
+		if cmds == nil {
+			cmds, err = p.Exec(ctx)
+		} else {
+			_, err = replayPipeline(ctx, cmds)
+		}
+		redisPipelineFlushes.Inc()
+		return err
+	})
+}
+
+// cronAggregateOptions are the options of the cron aggregations (maxTimeMS if configured)
+func cronAggregateOptions() *options.AggregateOptions {
+	opts := options.Aggregate().SetAllowDiskUse(true)
+	if cfg.AggMaxTimeMS > 0 {
+		opts.SetMaxTime(time.Duration(cfg.AggMaxTimeMS) * time.Millisecond)
+	}
+	return opts
+}
+
+// aggErr turns driver timeouts into an error that says which limit was hit
+func aggErr(name string, err error) error {
+	if !mongo.IsTimeout(err) {
+		return err
+	}
+	return fmt.Errorf("%s aggregation timed out (MONGO_AGGREGATION_CURSOR_TIMEOUT_MS=%d, MONGO_AGGREGATION_TIMEOUT_MIN=%d): %w",
+		name, cfg.AggMaxTimeMS, cfg.AggTimeoutMin, err)
+}
+
 // client_addr + miner_addr
-func computeAndStoreClientMiner(ctx context.Context) error {
+func computeAndStoreClientMiner(ctx context.Context) (n int, err error) {
+	ctx, span := startSpan(ctx, "cron.aggregate client+miner", attribute.String("db_name", cfg.MongoDB))
+	defer func() {
+		span.SetAttributes(attribute.Int("result_count", n))
+		endSpan(span, err)
+	}()
+
 	// Count only module=http; success rate = success(true)/total
 	pipeline := mongo.Pipeline{
 		{{Key: "$match", Value: bson.M{
@@ -157,18 +587,23 @@ func computeAndStoreClientMiner(ctx context.Context) error {
 		}}},
 	}
 
-	cur, err := colResult.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	cur, err := cronAggregate(ctx, pipeline)
 	if err != nil {
-		return err
+		return 0, aggErr("client+miner", err)
 	}
 	defer cur.Close(ctx)
 
-	// Build map: client -> []items
+	// Build map: client -> []items (plus client-level totals for the client ZSET)
 	group := make(map[string][]ClientMinerItem, 40000)
+	totals := make(map[string][2]int64, 40000) // client -> {total, ok}
+	byMiner := make(map[string][]redis.Z)      // miner -> clients scored by check count
 	for cur.Next(ctx) {
+		if err := ctx.Err(); err != nil {
+			return 0, fmt.Errorf("client+miner aggregation cancelled: %w", err)
+		}
 		var a aggOut2Keys
 		if err := cur.Decode(&a); err != nil {
-			return err
+			return 0, err
 		}
 		if a.ID.Client == "" || a.ID.Miner == "" || a.Total == 0 {
 			continue
@@ -180,154 +615,474 @@ func computeAndStoreClientMiner(ctx context.Context) error {
 			SuccessRateHTTP:      r,
 			SuccessRateGraphsync: 0,
 			SuccessRateBitswap:   0,
+			TotalChecks:          a.Total,
 		}
+		it.CompositeScore = compositeScore(it.SuccessRateHTTP, it.SuccessRateGraphsync, it.SuccessRateBitswap)
 		group[a.ID.Client] = append(group[a.ID.Client], it)
+		t := totals[a.ID.Client]
+		totals[a.ID.Client] = [2]int64{t[0] + a.Total, t[1] + a.OK}
+		byMiner[a.ID.Miner] = append(byMiner[a.ID.Miner], redis.Z{Member: a.ID.Client, Score: float64(a.Total)})
 	}
 	if err := cur.Err(); err != nil {
-		return err
+		return 0, aggErr("client+miner", err)
 	}
 
-	// Write back to Redis: one client = one key (value is a JSON array)
-	pipe := rds.Pipeline()
+	// Write back to Redis: one client = one key (value is a JSON array). Everything goes to shadow keys
+	// first and is swapped in at the end in chunks of swapChunk renames. Tradeoff: every key flips
+	// atomically and is never half-written, but during the swap (milliseconds) /clients can serve some
+	// clients from this run and some from the previous one; a single script over all 40k+ keys would
+	// make the switch atomic but block every other Redis reader for its whole duration. The client
+	// ZSET goes last, so it never lists a client whose doc is not swapped in yet.
+	pipe := newBatchPipe()
+	pipe.Del(ctx, zsetClientHTTP+shadowSuffix) // Rebuilt on every run, like the miner index
+	live := make([]string, 0, len(group)+len(byMiner)+1)
+	written := 0
 	for client, list := range group {
+		if err := pipe.maybeFlush(ctx); err != nil {
+			return 0, fmt.Errorf("client+miner write-back failed after %d clients: %w", written, err)
+		}
+		written++
 		// For UI convenience, store sorted by HTTP success rate (desc)
 		sort.Slice(list, func(i, j int) bool { return list[i].SuccessRateHTTP > list[j].SuccessRateHTTP })
-		bz, _ := json.Marshal(list)
-		pipe.Set(ctx, keyClientPrefix+client, string(bz), redisTTL)
+		val, err := marshalDoc(keyClientPrefix+client, list)
+		if err != nil {
+			return 0, err
+		}
+		pipe.Set(ctx, keyClientPrefix+client+shadowSuffix, val, redisTTL)
+		live = append(live, keyClientPrefix+client)
+		t := totals[client]
+		pipe.ZAdd(ctx, zsetClientHTTP+shadowSuffix, redis.Z{Member: client, Score: float64(t[1]) / float64(t[0])})
 	}
-	_, err = pipe.Exec(ctx)
-	return err
+	// Reverse index for /miners/<miner_addr>/clients, swapped in with the client docs it points to
+	for miner, zs := range byMiner {
+		if err := pipe.maybeFlush(ctx); err != nil {
+			return 0, fmt.Errorf("client+miner write-back failed: %w", err)
+		}
+		key := zsetMinerClients + miner
+		pipe.Del(ctx, key+shadowSuffix)
+		pipe.ZAdd(ctx, key+shadowSuffix, zs...)
+		pipe.Expire(ctx, key+shadowSuffix, redisTTL)
+		live = append(live, key)
+	}
+	if len(group) == 0 {
+		pipe.Del(ctx, zsetClientHTTP) // no shadow ZSET to swap in
+	} else {
+		live = append(live, zsetClientHTTP)
+	}
+	if err := pipe.flush(ctx); err != nil {
+		return 0, err
+	}
+	if _, err := swapShadowKeys(ctx, live); err != nil {
+		return 0, fmt.Errorf("client+miner shadow key swap failed: %w", err)
+	}
+	return len(group), nil
 }
 
-// miner_addr
-func computeAndStoreMiner(ctx context.Context) error {
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"task.module": "http",
-			// "created_at": bson.M{"$gte": time.Now().Add(-24 * time.Hour)},
-		}}},
-		{{Key: "$group", Value: bson.M{
-			"_id":   "$task.provider.id",
-			"total": bson.M{"$sum": 1},
-			"ok":    bson.M{"$sum": bson.M{"$cond": []any{"$result.success", 1, 0}}},
-		}}},
+// marshalDoc encodes a cron write-back value; the error names the Redis key so a bad document
+// (e.g. a NaN rate) fails the run instead of storing an empty value
+func marshalDoc(key string, v any) (string, error) {
+	bz, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("marshal %s: %w", key, err)
 	}
+	return string(bz), nil
+}
+
+// miner_addr
+func computeAndStoreMiner(ctx context.Context) (n int, err error) {
+	ctx, span := startSpan(ctx, "cron.aggregate miner", attribute.String("db_name", cfg.MongoDB))
+	defer func() {
+		span.SetAttributes(attribute.Int("result_count", n))
+		endSpan(span, err)
+	}()
 
-	cur, err := colResult.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	cur, err := cronAggregate(ctx, minerPipeline(bson.M{
+		"task.module": "http",
+		// "created_at": bson.M{"$gte": time.Now().Add(-24 * time.Hour)},
+	}))
 	if err != nil {
-		return err
+		return 0, aggErr("miner", err)
 	}
 	defer cur.Close(ctx)
 
-	pipe := rds.Pipeline()
+	now := float64(time.Now().Unix())
+	today := time.Now().UTC().Format(snapshotDateLayout)
+	// Continent ZSETs of the previous run; a miner may have moved or its continent may now be unknown
+	oldConts, err := rds.SMembers(ctx, setMinerConts).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	pipe := newBatchPipe()
 	pipe.Del(ctx, zsetMinerHTTP) // Rebuild the index; differential updates are also possible
+	pipe.Del(ctx, zsetMinerTested)
+	for _, c := range oldConts {
+		pipe.Del(ctx, zsetMinerCont+c)
+	}
+	pipe.Del(ctx, setMinerConts)
+	updated := 0
 	for cur.Next(ctx) {
+		if err := ctx.Err(); err != nil {
+			return 0, fmt.Errorf("miner aggregation cancelled: %w", err)
+		}
 		var a aggOut1Key
 		if err := cur.Decode(&a); err != nil {
-			return err
+			return 0, err
 		}
 		if a.ID == "" || a.Total == 0 {
 			continue
 		}
-		r := float64(a.OK) / float64(a.Total)
-		doc := RateDoc{SuccessRateHTTP: r, SuccessRateGraphsync: 0, SuccessRateBitswap: 0}
-		bz, _ := json.Marshal(doc)
-		pipe.Set(ctx, keyMinerPrefix+a.ID, string(bz), redisTTL)
-		pipe.ZAdd(ctx, zsetMinerHTTP, redis.Z{Member: a.ID, Score: r})
+		if err := queueMinerDoc(ctx, pipe, a, today, now); err != nil {
+			return 0, err
+		}
+		updated++
+		if err := pipe.maybeFlush(ctx); err != nil {
+			return 0, fmt.Errorf("miner write-back failed after %d miners: %w", updated, err)
+		}
 	}
 	if err := cur.Err(); err != nil {
+		return 0, aggErr("miner", err)
+	}
+	if err := pipe.flush(ctx); err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// minerPipeline aggregates the results selected by match into one aggOut1Key per miner
+func minerPipeline(match bson.M) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		// Per (miner, asn, isp) first, so the most common network of each miner can be picked below
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"miner": "$task.provider.id",
+				"asn":   "$task.provider.asn",
+				"isp":   "$task.provider.isp",
+			},
+			"total": bson.M{"$sum": 1},
+			"ok":    bson.M{"$sum": bson.M{"$cond": []any{"$result.success", 1, 0}}},
+			// Provider location (resolved by the task creator from the miner's multiaddrs)
+			"city":      bson.M{"$first": "$task.provider.city"},
+			"region":    bson.M{"$first": "$task.provider.region"},
+			"country":   bson.M{"$first": "$task.provider.country"},
+			"continent": bson.M{"$first": "$task.provider.continent"},
+			"last_at":   bson.M{"$max": "$created_at"},
+			// Newest success in the same pass ($max ignores the nulls of failed checks)
+			"last_ok_at": bson.M{"$max": bson.M{"$cond": []any{"$result.success", "$created_at", nil}}},
+			// Multiaddrs of the newest check that has some: the input is not sorted, so instead of $last this
+			// takes the $max of {at, addrs}, which documents compare by their first field
+			"last_maddrs": bson.M{"$max": bson.M{"$cond": []any{
+				bson.M{"$gt": []any{bson.M{"$size": bson.M{"$ifNull": []any{"$task.provider.multiaddrs", bson.A{}}}}, 0}},
+				bson.D{{Key: "at", Value: "$created_at"}, {Key: "addrs", Value: "$task.provider.multiaddrs"}},
+				nil,
+			}}},
+		}}},
+		// Known ASN first (older results have none), then by number of checks
+		{{Key: "$addFields", Value: bson.M{
+			"known": bson.M{"$cond": []any{bson.M{"$gt": []any{bson.M{"$ifNull": []any{"$_id.asn", ""}}, ""}}, 1, 0}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "known", Value: -1}, {Key: "total", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$_id.miner",
+			"total":       bson.M{"$sum": "$total"},
+			"ok":          bson.M{"$sum": "$ok"},
+			"city":        bson.M{"$first": "$city"},
+			"region":      bson.M{"$first": "$region"},
+			"country":     bson.M{"$first": "$country"},
+			"continent":   bson.M{"$first": "$continent"},
+			"asn":         bson.M{"$first": "$_id.asn"},
+			"isp":         bson.M{"$first": "$_id.isp"},
+			"last_at":     bson.M{"$max": "$last_at"},
+			"last_ok_at":  bson.M{"$max": "$last_ok_at"},
+			"last_maddrs": bson.M{"$max": "$last_maddrs"},
+		}}},
+	}
+}
+
+// queueMinerDoc queues the Redis writes of one aggregated miner: its doc, the day's snapshot, the provider
+// doc and its entries in the ranking ZSETs. Nothing is deleted, the full run clears the ZSETs beforehand.
+func queueMinerDoc(ctx context.Context, pipe redis.Pipeliner, a aggOut1Key, today string, now float64) error {
+	r := float64(a.OK) / float64(a.Total)
+	doc := RateDoc{
+		SuccessRateHTTP:      r,
+		SuccessRateGraphsync: 0,
+		SuccessRateBitswap:   0,
+		TotalHTTP:            a.Total,
+		City:                 a.City,
+		Region:               a.Region,
+		Country:              a.Country,
+		Continent:            a.Continent,
+		ASN:                  a.ASN,
+		ISP:                  a.ISP,
+	}
+	if !a.LastAt.IsZero() {
+		doc.LastCheckedAt = a.LastAt.UTC().Format(time.RFC3339)
+		pipe.ZAdd(ctx, zsetMinerTested, redis.Z{Member: a.ID, Score: float64(a.LastAt.Unix())})
+	}
+	if !a.LastOKAt.IsZero() {
+		t := a.LastOKAt.UTC()
+		doc.LastSuccessAt = &t
+	}
+	val, err := marshalDoc(keyMinerPrefix+a.ID, doc)
+	if err != nil {
 		return err
 	}
-	_, err = pipe.Exec(ctx)
-	return err
+	pipe.Set(ctx, keyMinerPrefix+a.ID, val, redisTTL)
+	pipe.Set(ctx, minerSnapshotKey(a.ID, today), val, minerSnapshotTTL) // the day's last run wins
+	if a.LastMaddrs != nil {
+		pd := ProviderDoc{Multiaddrs: a.LastMaddrs.Addrs, ObservedAt: a.LastMaddrs.At.UTC().Format(time.RFC3339)}
+		val, err := marshalDoc(keyProviderPrefix+a.ID, pd)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, keyProviderPrefix+a.ID, val, redisTTL)
+	}
+	pipe.ZAdd(ctx, zsetMinerHTTP, redis.Z{Member: a.ID, Score: r})
+	if a.Continent != "" {
+		pipe.ZAdd(ctx, zsetMinerCont+a.Continent, redis.Z{Member: a.ID, Score: r})
+		pipe.SAdd(ctx, setMinerConts, a.Continent)
+	}
+	pipe.ZAddNX(ctx, zsetFirstSeen, redis.Z{Member: a.ID, Score: now}) // keep the original timestamp
+	return nil
 }
 
 // ============= HTTP =============
 
-// /miners?miner_addr=&page=&page_size=
+// minerItems loads stats:miner:<id> for all ids in one pipeline; expired keys are skipped
+func minerItems(ctx context.Context, ids []string, v int, card bool) ([]map[string]any, error) {
+	items := make([]map[string]any, 0, len(ids))
+	if len(ids) == 0 {
+		return items, nil
+	}
+	pipe := rds.Pipeline()
+	cmds := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.Get(ctx, keyMinerPrefix+id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	for i, id := range ids {
+		val, err := cmds[i].Result()
+		if err != nil {
+			continue
+		}
+		var rd RateDoc
+		_ = json.Unmarshal([]byte(val), &rd)
+		if card {
+			items = append(items, minerCard(id, rd, v))
+		} else {
+			items = append(items, minerItem(id, rd, v))
+		}
+	}
+	return items, nil
+}
+
+// rateRange is the optional min_success_rate/max_success_rate filter of /miners
+type rateRange struct {
+	set      bool
+	lo, hi   float64
+	min, max string // ZRANGEBYSCORE bounds
+}
+
+func (rr rateRange) contains(score float64) bool {
+	return score >= rr.lo && score <= rr.hi
+}
+
+// parseRateRange parses the bounds; a missing bound is open (-inf / +inf)
+func parseRateRange(minS, maxS string) (rateRange, error) {
+	rr := rateRange{lo: math.Inf(-1), hi: math.Inf(1), min: "-inf", max: "+inf"}
+	if minS != "" {
+		f, err := strconv.ParseFloat(minS, 64)
+		if err != nil || math.IsNaN(f) {
+			return rr, errors.New("min_success_rate must be a number")
+		}
+		rr.set, rr.lo, rr.min = true, f, minS
+	}
+	if maxS != "" {
+		f, err := strconv.ParseFloat(maxS, 64)
+		if err != nil || math.IsNaN(f) {
+			return rr, errors.New("max_success_rate must be a number")
+		}
+		rr.set, rr.hi, rr.max = true, f, maxS
+	}
+	if rr.lo > rr.hi {
+		return rr, errors.New("min_success_rate must be <= max_success_rate")
+	}
+	return rr, nil
+}
+
+// sortByScoreThenID orders by score desc, breaking ties by member (miner id) asc or desc
+func sortByScoreThenID(zs []redis.Z, idAsc bool) {
+	sort.Slice(zs, func(i, j int) bool {
+		if zs[i].Score != zs[j].Score {
+			return zs[i].Score > zs[j].Score
+		}
+		a, _ := zs[i].Member.(string)
+		b, _ := zs[j].Member.(string)
+		if idAsc {
+			return a < b
+		}
+		return a > b
+	})
+}
+
+// minerRankKey returns the ranking ZSET for the given continents: idx:miners:http when none is given,
+// the continent's own ZSET for one, and a short-lived ZUNIONSTORE of their ZSETs for several.
+func minerRankKey(ctx context.Context, continents []string) (string, error) {
+	if len(continents) == 0 {
+		return zsetMinerHTTP, nil
+	}
+	names := make([]string, 0, len(continents))
+	seen := make(map[string]struct{}, len(continents))
+	for _, c := range continents {
+		c = strings.ToUpper(c)
+		if _, ok := seen[c]; !ok {
+			seen[c] = struct{}{}
+			names = append(names, c)
+		}
+	}
+	if len(names) == 1 {
+		return zsetMinerCont + names[0], nil
+	}
+	sort.Strings(names)
+	keys := make([]string, len(names))
+	for i, c := range names {
+		keys[i] = zsetMinerCont + c
+	}
+	dst := tmpMinerConts + strings.Join(names, ",")
+	pipe := rds.TxPipeline()
+	pipe.ZUnionStore(ctx, dst, &redis.ZStore{Keys: keys, Aggregate: "MAX"})
+	pipe.Expire(ctx, dst, tmpMinerContsTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// /miners?miner_addr=&continent=&page=&page_size=
 // - If miner_addr is provided: return only that miner (no pagination)
 // - Otherwise: paginate from ZSET sorted by HTTP success rate (desc)
+// - sort_tie_break=miner_addr_asc|miner_addr_desc orders miners with equal rates (default asc)
+// - continent=NA,EU ranks within the union of the per-continent ZSETs instead of idx:miners:http
 func handleMiners(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	q := r.URL.Query()
 	minerQ := q.Get("miner_addr")
+	v := apiVersion(r)
+	withVolume := q.Get("include_data_volume") == "true" // vol:miner:<id> from the cron, one MGET
 
 	// Pagination parameters
 	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
 	start := int64((page - 1) * pageSize)
 	end := start + int64(pageSize) - 1
 
+	rr, err := parseRateRange(q.Get("min_success_rate"), q.Get("max_success_rate"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	idAsc := true
+	switch q.Get("sort_tie_break") {
+	case "", "miner_addr_asc":
+	case "miner_addr_desc":
+		idAsc = false
+	default:
+		http.Error(w, "sort_tie_break must be miner_addr_asc or miner_addr_desc", http.StatusBadRequest)
+		return
+	}
+	switch q.Get("sort_by") {
+	case "", "success_rate_http":
+	case "last_tested_at":
+		if minerQ != "" || rr.set || q.Get("continent") != "" {
+			http.Error(w, "sort_by=last_tested_at cannot be combined with miner_addr, continent or a success rate range", http.StatusBadRequest)
+			return
+		}
+		handleMinersByLastTested(w, r, page, pageSize, withVolume)
+		return
+	default:
+		http.Error(w, "sort_by must be success_rate_http or last_tested_at", http.StatusBadRequest)
+		return
+	}
+	zkey, err := minerRankKey(ctx, splitList(q.Get("continent")))
+	if err != nil {
+		http.Error(w, "redis zunionstore error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	// No query provided: use the original efficient path
 	if minerQ == "" {
-		ids, err := rds.ZRevRange(ctx, zsetMinerHTTP, start, end).Result()
+		zctx, span := startSpan(ctx, "redis.zrevrange "+zkey, attribute.Int("page", page))
+		var zs []redis.Z
+		if rr.set {
+			zs, err = rds.ZRevRangeByScoreWithScores(zctx, zkey, &redis.ZRangeBy{
+				Min: rr.min, Max: rr.max, Offset: start, Count: int64(pageSize),
+			}).Result()
+		} else {
+			zs, err = rds.ZRevRangeWithScores(zctx, zkey, start, end).Result()
+		}
+		span.SetAttributes(attribute.Int("result_count", len(zs)))
+		endSpan(span, err)
 		if err != nil {
 			http.Error(w, "redis zset error: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
-		items := make([]map[string]string, 0, len(ids))
-		for _, id := range ids {
-			val, err := rds.Get(ctx, keyMinerPrefix+id).Result()
-			if err != nil {
-				if errors.Is(err, redis.Nil) {
-					continue
-				}
-				http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
-			var rd RateDoc
-			_ = json.Unmarshal([]byte(val), &rd)
-			items = append(items, map[string]string{
-				"miner_id":               id,
-				"success_rate_http":      pct(rd.SuccessRateHTTP),
-				"success_rate_graphsync": pct(rd.SuccessRateGraphsync),
-				"success_rate_bitswap":   pct(rd.SuccessRateBitswap),
-			})
-		}
-		// Total count
-		total, _ := rds.ZCard(ctx, zsetMinerHTTP).Result()
-		writeJSON(w, map[string]any{
+		// Ties are only re-ordered within the page; the ZSET decides which miners land on it
+		sortByScoreThenID(zs, idAsc)
+		ids := make([]string, len(zs))
+		for i, z := range zs {
+			ids[i], _ = z.Member.(string)
+		}
+		items, err := minerItems(ctx, ids, v, false)
+		if err == nil && withVolume {
+			err = attachDataVolume(ctx, items)
+		}
+		if err != nil {
+			http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Total count (within the score range, if any)
+		var total int64
+		if rr.set {
+			total, _ = rds.ZCount(ctx, zkey, rr.min, rr.max).Result()
+		} else {
+			total, _ = rds.ZCard(ctx, zkey).Result()
+		}
+		setTotalHeader(w, total)
+		writeJSONStream(w, map[string]any{
 			"page":      page,
 			"page_size": pageSize,
 			"total":     total,
-			"items":     items,
-		})
+		}, items)
 		return
 	}
 
 	// With miner_addr: fuzzy match (*keyword*), use ZSCAN to scan candidates, then sort by score descending and paginate
-	type ms struct {
-		id    string
-		score float64
+	zctx, span := startSpan(ctx, "redis.zscan "+zkey,
+		attribute.String("miner_addr", minerQ), attribute.Int("page", page))
+	matched, err := zscanAll(zctx, zkey, "*"+minerQ+"*")
+	span.SetAttributes(attribute.Int("result_count", len(matched)))
+	endSpan(span, err)
+	if err != nil {
+		http.Error(w, "redis zscan error: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	pattern := "*" + minerQ + "*"
-	var cursor uint64
-	var matched []ms
-
-	for {
-		// ZSCAN returns alternating [member, score, member, score, ...]
-		keys, next, err := rds.ZScan(ctx, zsetMinerHTTP, cursor, pattern, 1000).Result()
-		if err != nil {
-			http.Error(w, "redis zscan error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		// Parse alternating pairs
-		for i := 0; i+1 < len(keys); i += 2 {
-			member := keys[i]
-			scoreStr := keys[i+1]
-			sc, _ := strconv.ParseFloat(scoreStr, 64)
-			matched = append(matched, ms{id: member, score: sc})
-		}
-		cursor = next
-		if cursor == 0 {
-			break
+	// Apply the score range, then sort by score descending
+	if rr.set {
+		kept := matched[:0]
+		for _, z := range matched {
+			if rr.contains(z.Score) {
+				kept = append(kept, z)
+			}
 		}
+		matched = kept
 	}
-
-	// Sort by score descending
-	sort.Slice(matched, func(i, j int) bool { return matched[i].score > matched[j].score })
+	sortByScoreThenID(matched, idAsc)
 
 	total := int64(len(matched))
+	setTotalHeader(w, total)
 	if start >= total {
 		writeJSON(w, map[string]any{
 			"page":      page,
@@ -345,32 +1100,80 @@ func handleMiners(w http.ResponseWriter, r *http.Request) {
 	}
 	pageMs := matched[start:endIdx]
 
-	items := make([]map[string]string, 0, len(pageMs))
+	ids := make([]string, 0, len(pageMs))
 	for _, it := range pageMs {
-		val, err := rds.Get(ctx, keyMinerPrefix+it.id).Result()
-		if err != nil {
-			if errors.Is(err, redis.Nil) {
-				continue
-			}
-			http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		var rd RateDoc
-		_ = json.Unmarshal([]byte(val), &rd)
-		items = append(items, map[string]string{
-			"miner_id":               it.id,
-			"success_rate_http":      pct(rd.SuccessRateHTTP),
-			"success_rate_graphsync": pct(rd.SuccessRateGraphsync),
-			"success_rate_bitswap":   pct(rd.SuccessRateBitswap),
-		})
+		id, _ := it.Member.(string)
+		ids = append(ids, id)
+	}
+	items, err := minerItems(ctx, ids, v, true)
+	if err == nil && withVolume {
+		err = attachDataVolume(ctx, items)
+	}
+	if err != nil {
+		http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	writeJSON(w, map[string]any{
+	writeJSONStream(w, map[string]any{
 		"page":      page,
 		"page_size": pageSize,
 		"total":     total, // Total count of fuzzy matches
-		"items":     items,
-	})
+	}, items)
+}
+
+// zscanAll collects every ZSET member matching pattern (ZSCAN returns alternating [member, score, ...])
+func zscanAll(ctx context.Context, key, pattern string) ([]redis.Z, error) {
+	var cursor uint64
+	var matched []redis.Z
+	for {
+		keys, next, err := rds.ZScan(ctx, key, cursor, pattern, 1000).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+1 < len(keys); i += 2 {
+			sc, _ := strconv.ParseFloat(keys[i+1], 64)
+			matched = append(matched, redis.Z{Member: keys[i], Score: sc})
+		}
+		cursor = next
+		if cursor == 0 {
+			return matched, nil
+		}
+	}
+}
+
+// Response item for a single miner (shared by /miners and /miners/stream)
+func minerItem(id string, rd RateDoc, v int) map[string]any {
+	return map[string]any{
+		"miner_id":                id,
+		"success_rate_http":       rateValue(v, rd.SuccessRateHTTP),
+		"success_rate_graphsync":  rateValue(v, rd.SuccessRateGraphsync),
+		"success_rate_bitswap":    rateValue(v, rd.SuccessRateBitswap),
+		"days_since_last_success": daysSinceLastSuccess(rd, time.Now()),
+	}
+}
+
+// daysSinceLastSuccess returns the fractional days since the miner's last successful HTTP check,
+// or -1 if it never had one
+func daysSinceLastSuccess(rd RateDoc, now time.Time) float64 {
+	if rd.LastSuccessAt == nil {
+		return -1
+	}
+	return math.Round(now.Sub(*rd.LastSuccessAt).Hours()/24*100) / 100
+}
+
+// minerCard is the miner_addr lookup form of minerItem: rates plus location and check metadata,
+// so a frontend can render a provider card without calling /details
+func minerCard(id string, rd RateDoc, v int) map[string]any {
+	it := minerItem(id, rd, v)
+	it["city"] = rd.City
+	it["region"] = rd.Region
+	it["country"] = rd.Country
+	it["continent"] = rd.Continent
+	it["asn"] = rd.ASN
+	it["isp"] = rd.ISP
+	it["check_count"] = rd.TotalHTTP
+	it["last_checked_at"] = rd.LastCheckedAt
+	return it
 }
 
 // /clients?client_addr=&page=&page_size=
@@ -385,10 +1188,16 @@ func handleClients(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "client_addr is required", http.StatusBadRequest)
 		return
 	}
+	sortKey, ok := clientMinerSortKeys[q.Get("sort_by")]
+	if !ok {
+		http.Error(w, "sort_by must be http, graphsync, bitswap or composite", http.StatusBadRequest)
+		return
+	}
 
 	val, err := rds.Get(ctx, keyClientPrefix+client).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
+			setTotalHeader(w, 0)
 			writeJSON(w, map[string]any{"count": 0, "items": []any{}})
 			return
 		}
@@ -401,10 +1210,11 @@ func handleClients(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "decode error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	// Ensure descending order by HTTP success rate
-	sort.Slice(list, func(i, j int) bool { return list[i].SuccessRateHTTP > list[j].SuccessRateHTTP })
+	// Descending by the chosen rate (stored lists are already sorted by HTTP)
+	sort.SliceStable(list, func(i, j int) bool { return sortKey(list[i]) > sortKey(list[j]) })
 
 	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
+	setTotalHeader(w, int64(len(list)))
 	start := (page - 1) * pageSize
 	if start >= len(list) {
 		writeJSON(w, map[string]any{
@@ -421,36 +1231,44 @@ func handleClients(w http.ResponseWriter, r *http.Request) {
 	}
 	sub := list[start:end]
 
-	items := make([]map[string]string, 0, len(sub))
+	v := apiVersion(r)
+	items := make([]map[string]any, 0, len(sub))
 	for _, it := range sub {
-		items = append(items, map[string]string{
+		items = append(items, map[string]any{
 			"client_id":              it.ClientAddr,
 			"miner_id":               it.MinerAddr,
-			"success_rate_http":      pct(it.SuccessRateHTTP),
-			"success_rate_graphsync": pct(it.SuccessRateGraphsync),
-			"success_rate_bitswap":   pct(it.SuccessRateBitswap),
+			"success_rate_http":      rateValue(v, it.SuccessRateHTTP),
+			"success_rate_graphsync": rateValue(v, it.SuccessRateGraphsync),
+			"success_rate_bitswap":   rateValue(v, it.SuccessRateBitswap),
+			"composite_score":        rateValue(v, it.CompositeScore),
 		})
 	}
 
-	writeJSON(w, map[string]any{
+	writeJSONStream(w, map[string]any{
 		"page":      page,
 		"page_size": pageSize,
 		"total":     len(list),
-		"items":     items,
-	})
+	}, items)
 }
 
-// /details?miner_addr=...|client_addr=...&status=0|1&retrieval_method=http&page=&page_size=
-func handleDetails(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	q := r.URL.Query()
+// clientMinerSortKeys maps /clients sort_by values to the rate they sort on ("" = http)
+var clientMinerSortKeys = map[string]func(ClientMinerItem) float64{
+	"":          func(it ClientMinerItem) float64 { return it.SuccessRateHTTP },
+	"http":      func(it ClientMinerItem) float64 { return it.SuccessRateHTTP },
+	"graphsync": func(it ClientMinerItem) float64 { return it.SuccessRateGraphsync },
+	"bitswap":   func(it ClientMinerItem) float64 { return it.SuccessRateBitswap },
+	"composite": func(it ClientMinerItem) float64 { return it.CompositeScore },
+}
+
+// detailsFilter builds the /details filter; miner_addr and client_addr can be combined (AND),
+// which is served by the (module, provider, client, created_at) index
+func detailsFilter(q url.Values) (bson.M, error) {
 	method := q.Get("retrieval_method")
 	if method == "" {
 		method = "http"
 	}
 	if method != "http" {
-		http.Error(w, "only http supported", http.StatusBadRequest)
-		return
+		return nil, errors.New("only http supported")
 	}
 
 	filter := bson.M{"task.module": method}
@@ -467,70 +1285,181 @@ func handleDetails(w http.ResponseWriter, r *http.Request) {
 		case "1":
 			filter["result.success"] = false
 		default:
-			http.Error(w, "status must be 0 or 1", http.StatusBadRequest)
-			return
+			return nil, errors.New("status must be 0 or 1")
 		}
 	}
+	// error_code=TIMEOUT,CONNECTION_REFUSED
+	if codes := splitList(q.Get("error_code")); len(codes) == 1 {
+		filter["result.error_code"] = codes[0]
+	} else if len(codes) > 1 {
+		filter["result.error_code"] = bson.M{"$in": codes}
+	}
+	return filter, nil
+}
 
-	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
-	skip := int64((page - 1) * pageSize)
-	limit := int64(pageSize)
-
-	// First get the total count
-	total, err := colResult.CountDocuments(ctx, filter)
+// /details?miner_addr=...&client_addr=...&status=0|1&retrieval_method=http&page=&page_size=
+// /details?...&cursor=<next_cursor>   (cursor pagination; mutually exclusive with page/page_size)
+func handleDetails(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	filter, err := detailsFilter(q)
 	if err != nil {
-		http.Error(w, "mongo count error: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	opts := options.Find().
-		SetSort(bson.D{{Key: "created_at", Value: -1}}).
-		SetSkip(skip).
-		SetLimit(limit)
-
-	cur, err := colResult.Find(ctx, filter, opts)
-	if err != nil {
-		http.Error(w, "mongo find error: "+err.Error(), http.StatusInternalServerError)
+	// Sort direction; both directions walk the created_at indexes (forward or backward)
+	asc := false
+	switch q.Get("order_by") {
+	case "", "created_at_desc":
+	case "created_at_asc":
+		asc = true
+	default:
+		http.Error(w, "order_by must be created_at_asc or created_at_desc", http.StatusBadRequest)
 		return
 	}
-	defer cur.Close(ctx)
 
-	type Row struct {
-		MinerID         string      `json:"miner_id"`
-		CID             string      `json:"cid"`
-		Status          bool        `json:"status"`
-		ReturnCode      string      `json:"return_code"`
-		ResponseMessage string      `json:"response_message"`
-		CreationTime    interface{} `json:"creation_time"`
+	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
+	skip := int64((page - 1) * pageSize)
+
+	// Cursor mode: position and page size come from the cursor, page/page_size are not allowed
+	cursorTok := q.Get("cursor")
+	if cursorTok != "" {
+		if q.Get("page") != "" || q.Get("page_size") != "" {
+			http.Error(w, "cursor and page/page_size are mutually exclusive", http.StatusBadRequest)
+			return
+		}
+		c, err := decodeCursor(cursorTok)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if q.Get("order_by") != "" && c.Asc != asc {
+			http.Error(w, "order_by does not match the cursor", http.StatusBadRequest)
+			return
+		}
+		asc = c.Asc
+		for k, v := range c.filter() {
+			filter[k] = v
+		}
+		pageSize = c.PageSize
+		skip = 0
+	}
+	limit := int64(pageSize)
+
+	dir := -1
+	if asc {
+		dir = 1
+	}
+	sortBy := bson.D{{Key: "created_at", Value: dir}, {Key: "_id", Value: dir}}
+	spanAttrs := []attribute.KeyValue{
+		attribute.String("db_name", cfg.MongoDB), attribute.String("miner_addr", q.Get("miner_addr")), attribute.Int("page", page),
 	}
 
-	var items []Row
-	for cur.Next(ctx) {
-		var m bson.M
-		if err := cur.Decode(&m); err != nil {
-			http.Error(w, "decode error: "+err.Error(), http.StatusInternalServerError)
+	resp := map[string]any{"page_size": pageSize}
+	var docs []TaskResultDoc
+	if cursorTok == "" && q.Get("total_hint") != "false" {
+		// Page and total count in one $facet round trip
+		actx, span := startSpan(ctx, "mongo.aggregate_facet "+colResult.Name(), spanAttrs...)
+		var total int64
+		err = withBreaker(detailsBreaker, func() (err error) {
+			docs, total, err = findDetailsPageWithTotal(actx, filter, sortBy, skip, limit)
+			return err
+		})
+		span.SetAttributes(attribute.Int("result_count", len(docs)))
+		endSpan(span, err)
+		if breakerRejected(err) {
+			writeBreakerOpen(w)
 			return
 		}
-		items = append(items, Row{
-			MinerID:         getString(m, "task", "provider", "id"),
-			CID:             getString(m, "task", "content", "cid"),
-			Status:          getBool(m, "result", "success"),
-			ReturnCode:      getString(m, "result", "error_code"),
-			ResponseMessage: getString(m, "result", "error_message"),
-			CreationTime:    m["created_at"],
+		if err != nil {
+			http.Error(w, "mongo aggregate error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp["page"] = page
+		resp["count"] = total // Use total count from database
+		setTotalHeader(w, total)
+	} else {
+		// Cursor mode (which exists to avoid full scans) and total_hint=false skip the count
+		fctx, span := startSpan(ctx, "mongo.find "+colResult.Name(), spanAttrs...)
+		err = withBreaker(detailsBreaker, func() (err error) {
+			docs, err = findDetailsPage(fctx, filter, sortBy, skip, limit)
+			return err
 		})
+		span.SetAttributes(attribute.Int("result_count", len(docs)))
+		endSpan(span, err)
+		if breakerRejected(err) {
+			writeBreakerOpen(w)
+			return
+		}
+		if err != nil {
+			http.Error(w, "mongo find error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if cursorTok == "" {
+			resp["page"] = page
+			resp["count"] = -1 // total_hint=false: total unknown
+		}
 	}
-	if err := cur.Err(); err != nil {
-		http.Error(w, "cursor error: "+err.Error(), http.StatusInternalServerError)
-		return
+
+	items := make([]DetailsRow, 0, len(docs))
+	for _, doc := range docs {
+		items = append(items, detailsRow(doc))
 	}
 
-	writeJSON(w, map[string]any{
-		"page":      page,
-		"page_size": pageSize,
-		"count":     total, // Use total count from database
-		"items":     items, // Current page data
-	})
+	// A full page means there may be more rows: hand out the cursor to continue from here
+	if len(docs) == pageSize {
+		last := docs[len(docs)-1]
+		resp["next_cursor"] = cursorAfter(last.ID, last.CreatedAt, pageSize, asc)
+	}
+	writeJSONStream(w, resp, items) // Current page data
+}
+
+// findDetailsPage returns one page of task results (no count)
+func findDetailsPage(ctx context.Context, filter bson.M, sortBy bson.D, skip, limit int64) ([]TaskResultDoc, error) {
+	opts := options.Find().SetSort(sortBy).SetSkip(skip).SetLimit(limit)
+	cur, err := colResult.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []TaskResultDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// findDetailsPageWithTotal returns one page of task results and the total number of matches,
+// using a single $facet aggregation instead of CountDocuments + Find
+func findDetailsPageWithTotal(ctx context.Context, filter bson.M, sortBy bson.D, skip, limit int64) ([]TaskResultDoc, int64, error) {
+	cur, err := colResult.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: sortBy}},
+		{{Key: "$facet", Value: bson.M{
+			"items": bson.A{bson.M{"$skip": skip}, bson.M{"$limit": limit}},
+			"total": bson.A{bson.M{"$count": "n"}},
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, 0, err
+	}
+	var out []struct {
+		Items []TaskResultDoc `bson:"items"`
+		Total []struct {
+			N int64 `bson:"n"`
+		} `bson:"total"`
+	}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, 0, err
+	}
+	if len(out) == 0 {
+		return nil, 0, nil
+	}
+	var total int64
+	if len(out[0].Total) > 0 {
+		total = out[0].Total[0].N
+	}
+	return out[0].Items, total, nil
 }
 
 // ============= utils =============
@@ -541,6 +1470,19 @@ func getenv(k, def string) string {
 	}
 	return def
 }
+
+// parseStatsPeriod validates STATS_PERIOD_MIN (whole minutes, at least minStatsPeriod)
+func parseStatsPeriod(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("STATS_PERIOD_MIN %q is not an integer", s)
+	}
+	if n < minStatsPeriod {
+		return 0, fmt.Errorf("STATS_PERIOD_MIN must be at least %d minutes, got %d", minStatsPeriod, n)
+	}
+	return n, nil
+}
+
 func mustAtoi(s string) int {
 	n, err := strconv.Atoi(s)
 	if err != nil {
@@ -548,8 +1490,50 @@ func mustAtoi(s string) int {
 	}
 	return n
 }
+
+func orDefault(s string) string {
+	if s == "" {
+		return "default"
+	}
+	return s
+}
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
 func pct(f float64) string { return fmt.Sprintf("%.2f%%", f*100) }
 
+// API response versions, selected with ?v=2 or the Accept-Version: 2 header:
+// - 1 (default): rates are pre-formatted percentage strings ("97.50%")
+// - 2: rates are float64 fractions in [0, 1]
+func apiVersion(r *http.Request) int {
+	v := r.URL.Query().Get("v")
+	if v == "" {
+		v = r.Header.Get("Accept-Version")
+	}
+	if v == "2" {
+		return 2
+	}
+	return 1
+}
+
+func rateValue(v int, f float64) any {
+	if v >= 2 {
+		return f
+	}
+	return pct(f)
+}
+
+// setTotalHeader mirrors the JSON total for pagination components; call it before writeJSON
+func setTotalHeader(w http.ResponseWriter, total int64) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+}
+
 func writeJSON(w http.ResponseWriter, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	enc := json.NewEncoder(w)
@@ -557,6 +1541,51 @@ func writeJSON(w http.ResponseWriter, v any) {
 	_ = enc.Encode(v)
 }
 
+// writeJSONStream writes fields plus an "items" array as one JSON object, encoding the items one by one
+// straight to w so a large page is never buffered as a whole. Keys are written in sorted order with
+// "items" last. Once the body has started, a write error just ends the response.
+func writeJSONStream[T any](w http.ResponseWriter, fields map[string]any, items []T) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return
+	}
+	for _, k := range keys {
+		kb, _ := json.Marshal(k)
+		if _, err := w.Write(append(kb, ':')); err != nil {
+			return
+		}
+		if err := enc.Encode(fields[k]); err != nil {
+			return
+		}
+		if _, err := io.WriteString(w, ","); err != nil {
+			return
+		}
+	}
+	if _, err := io.WriteString(w, `"items":[`); err != nil {
+		return
+	}
+	for i, it := range items {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return
+			}
+		}
+		if err := enc.Encode(it); err != nil {
+			return
+		}
+	}
+	_, _ = io.WriteString(w, "]}\n")
+}
+
 func parsePage(pStr, psStr string) (int, int) {
 	page := 1
 	if v, err := strconv.Atoi(pStr); err == nil && v > 0 {
@@ -569,43 +1598,46 @@ func parsePage(pStr, psStr string) (int, int) {
 	return page, ps
 }
 
-func getString(m bson.M, path ...string) string {
-	var cur any = m
-	for _, p := range path {
-		mm, ok := cur.(bson.M)
-		if !ok {
-			return ""
+// matchOrigin reports whether origin is allowed by one of the patterns.
+// A pattern is either an exact origin or contains a single "*" wildcard (e.g. "https://*.example.com").
+func matchOrigin(origin string, patterns []string) bool {
+	for _, p := range patterns {
+		if p == origin || p == "*" {
+			return true
 		}
-		cur = mm[p]
-	}
-	if s, ok := cur.(string); ok {
-		return s
-	}
-	return ""
-}
-func getBool(m bson.M, path ...string) bool {
-	var cur any = m
-	for _, p := range path {
-		mm, ok := cur.(bson.M)
-		if !ok {
-			return false
+		if i := strings.IndexByte(p, '*'); i >= 0 {
+			prefix, suffix := p[:i], p[i+1:]
+			if len(origin) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
 		}
-		cur = mm[p]
-	}
-	if b, ok := cur.(bool); ok {
-		return b
 	}
 	return false
 }
 
 // CORS middleware
+// - CORS_ALLOWED_ORIGINS empty: Allow-Origin "*" (legacy behavior)
+// - otherwise: echo the request Origin only if it matches the whitelist
 func withCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		if len(cfg.CORSOrigins) == 0 {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); origin != "" && matchOrigin(origin, cfg.CORSOrigins) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count")
 
 		if r.Method == http.MethodOptions {
+			// Let browsers cache the preflight instead of repeating it before every request
+			if cfg.CORSMaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(cfg.CORSMaxAge))
+			}
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
@@ -616,12 +1648,49 @@ func withCORS(next http.Handler) http.Handler {
 func main() {
 	mustInit()
 	startCron()
+	startChangeStream()
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/miners", handleMiners)
+	mux.HandleFunc("/miners/stream", handleMinersStream)
+	mux.HandleFunc("/miners/leaderboard", handleMinersLeaderboard)
+	mux.HandleFunc("/miners/count", handleMinersCount)
+	mux.HandleFunc("/miners/worst", handleMinersWorst)
+	mux.HandleFunc("/miners/geo", handleMinersGeo)
+	mux.HandleFunc("/miners/continent-summary", handleMinersContinentSummary)
+	mux.HandleFunc("/miners/new", handleMinersNew)
+	mux.HandleFunc("/miners/inactive", handleMinersInactive)
+	mux.HandleFunc("/miners/stale", handleMinersStale)
+	mux.HandleFunc("/miners/missing", handleMinersMissing)
+	mux.HandleFunc("/miners/overlap", handleMinersOverlap)
+	mux.HandleFunc("/miners/sector-count", handleMinersSectorCount)
+	mux.HandleFunc("/miners/data-volume", handleMinersDataVolume)
+	mux.HandleFunc("/miners/subscribe", handleMinersSubscribe)
+	mux.HandleFunc("/miners/subscribe/", handleMinersUnsubscribe)
+	mux.HandleFunc("/miners/", handleMinerPaths) // /miners/<miner_addr>/claims|clients
 	mux.HandleFunc("/clients", handleClients)
+	mux.HandleFunc("/clients/search", handleClientsSearch)
+	mux.HandleFunc("/clients/count", handleClientsCount)
+	mux.HandleFunc("/search", handleSearch)
+	mux.HandleFunc("/clients/", handleClientPaths) // /clients/<client_addr>/miners/best, /clients/<client_addr>/stats
+	mux.HandleFunc("/providers", handleProviders)
+	mux.HandleFunc("/providers/", handleProviderPaths) // /providers/<miner_addr>/peerid, .../multiaddrs, .../history, .../connectivity
+	mux.HandleFunc("/stats", handleStats)
+	mux.HandleFunc("/claims/stats", handleClaimsStats)
+	mux.HandleFunc("/deals", handleDeals)
+	mux.HandleFunc("/chain/head", handleChainHead)
 	mux.HandleFunc("/details", handleDetails)
+	mux.HandleFunc("/details/by_miner", handleDetailsByMiner)
+	mux.HandleFunc("/details/by_date", handleDetailsByDate)
+	mux.HandleFunc("/details/cid/", handleDetailsByCID)
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/metrics/redis", handleRedisMetrics)
+	mux.HandleFunc("/metrics/mongo", requireAdmin(handleMongoMetrics))
+	mux.HandleFunc("/admin/claims/prune", requireAdmin(handleAdminClaimsPrune))
+	mux.HandleFunc("/admin/reindex", requireAdmin(handleAdminReindex))
+	mux.HandleFunc("/admin/warm-cache", requireAdmin(handleAdminWarmCache))
+	mux.HandleFunc("/admin/ingest/progress", requireAdmin(handleAdminIngestProgress))
+	mux.HandleFunc("/admin/data-quality", requireAdmin(handleAdminDataQuality))
 
-	log.Printf("listening on %s", cfg.BindAddr)
-	log.Fatal(http.ListenAndServe(cfg.BindAddr, withCORS(mux)))
+	log.Fatal(listenAndServe(withTracing(withCORS(mux))))
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatsPeriod(t *testing.T) {
+	n, err := parseStatsPeriod("1440")
+	assert.NoError(t, err)
+	assert.Equal(t, 1440, n)
+
+	n, err = parseStatsPeriod("5")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	for _, bad := range []string{"4", "0", "-10", "", "1h"} {
+		_, err := parseStatsPeriod(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestDaysSinceLastSuccess(t *testing.T) {
+	now := time.Date(2025, 9, 10, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, -1.0, daysSinceLastSuccess(RateDoc{}, now))
+	at := func(t time.Time) *time.Time { return &t }
+	assert.Equal(t, 30.0, daysSinceLastSuccess(RateDoc{LastSuccessAt: at(now.AddDate(0, 0, -30))}, now))
+	assert.Equal(t, 0.5, daysSinceLastSuccess(RateDoc{LastSuccessAt: at(now.Add(-12 * time.Hour))}, now))
+}
+
+func TestSortByScoreThenID(t *testing.T) {
+	ids := func(zs []redis.Z) []any {
+		out := make([]any, len(zs))
+		for i, z := range zs {
+			out[i] = z.Member
+		}
+		return out
+	}
+	zs := []redis.Z{{Member: "f02", Score: 0.5}, {Member: "f03", Score: 0.9}, {Member: "f01", Score: 0.5}}
+	sortByScoreThenID(zs, true)
+	assert.Equal(t, []any{"f03", "f01", "f02"}, ids(zs))
+	sortByScoreThenID(zs, false)
+	assert.Equal(t, []any{"f03", "f02", "f01"}, ids(zs))
+}
+
+func TestWriteJSONStream(t *testing.T) {
+	fields := map[string]any{"page": 2, "page_size": 15, "total": int64(31), "note": "<a&b>"}
+	items := []map[string]any{{"miner_id": "f01", "rate": 0.5}, {"miner_id": "f02", "rate": 1.0}}
+
+	rec := httptest.NewRecorder()
+	writeJSONStream(rec, fields, items)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, "<a&b>", got["note"])
+	assert.Equal(t, 31.0, got["total"])
+	assert.Len(t, got["items"], 2)
+
+	rec = httptest.NewRecorder()
+	writeJSONStream[map[string]any](rec, map[string]any{}, nil)
+	assert.JSONEq(t, `{"items": []}`, rec.Body.String())
+}
+
+func TestMinerOverlap(t *testing.T) {
+	a := []ClientMinerItem{{MinerAddr: "f03", SuccessRateHTTP: 0.3}, {MinerAddr: "f01", SuccessRateHTTP: 0.1}, {MinerAddr: "f02"}}
+	b := []ClientMinerItem{{MinerAddr: "f01", SuccessRateHTTP: 0.9}, {MinerAddr: "f03", SuccessRateHTTP: 0.7}, {MinerAddr: "f03"}, {MinerAddr: "f04"}}
+
+	shared, union := minerOverlap(a, b)
+	assert.Equal(t, 4, union)
+	assert.Equal(t, []sharedMiner{{MinerAddr: "f01", RateA: 0.1, RateB: 0.9}, {MinerAddr: "f03", RateA: 0.3, RateB: 0.7}}, shared)
+
+	shared, union = minerOverlap(nil, b[:1])
+	assert.Empty(t, shared)
+	assert.Equal(t, 1, union)
+}
+
+func TestParseRedisInfo(t *testing.T) {
+	info := "# Memory\r\nused_memory:1048576\r\nused_memory_human:1.00M\r\nmaxmemory_human:0B\r\n\r\n# Keyspace\r\ndb0:keys=123,expires=45,avg_ttl=0\r\n"
+	m := parseRedisInfo(info)
+	assert.Equal(t, "1.00M", m["used_memory_human"])
+	assert.Equal(t, "0B", m["maxmemory_human"])
+
+	keys, expires := keyspaceCounts(m["db0"])
+	assert.Equal(t, int64(123), keys)
+	assert.Equal(t, int64(45), expires)
+	keys, _ = keyspaceCounts(m["db1"])
+	assert.Zero(t, keys)
+}
+
+func TestMarshalDoc(t *testing.T) {
+	val, err := marshalDoc("stats:miner:f01", RateDoc{SuccessRateHTTP: 0.5})
+	assert.NoError(t, err)
+	assert.Contains(t, val, `"success_rate_http":0.5`)
+
+	_, err = marshalDoc("stats:miner:f02", RateDoc{SuccessRateHTTP: math.NaN()})
+	assert.ErrorContains(t, err, "marshal stats:miner:f02")
+}
+
+func TestParseWeights(t *testing.T) {
+	w, err := parseWeights("0.6", "0.2", "0.2")
+	assert.NoError(t, err)
+	assert.Equal(t, WeightConfig{HTTP: 0.6, GraphSync: 0.2, Bitswap: 0.2}, w)
+
+	_, err = parseWeights("1", "0", "0.0000001")
+	assert.NoError(t, err, "within tolerance")
+	_, err = parseWeights("0.5", "0.2", "0.2")
+	assert.Error(t, err)
+	_, err = parseWeights("1.2", "-0.1", "-0.1")
+	assert.Error(t, err)
+	_, err = parseWeights("abc", "0.5", "0.5")
+	assert.Error(t, err)
+}
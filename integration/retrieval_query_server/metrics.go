@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics, served on /metrics
+var redisPipelineFlushes = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "redis_pipeline_flushes_total",
+	Help: "Number of Redis pipeline flushes during the cron write-back.",
+})
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	keyMongoMetrics = "cache:metrics:mongo"
+	mongoMetricsTTL = 5 * time.Minute
+)
+
+// collStats is the part of the collStats command output returned by /metrics/mongo (sizes in bytes)
+type collStats struct {
+	NS             string  `json:"ns" bson:"ns"`
+	Count          int64   `json:"count" bson:"count"`
+	AvgObjSize     float64 `json:"avgObjSize" bson:"avgObjSize"`
+	StorageSize    int64   `json:"storageSize" bson:"storageSize"`
+	TotalIndexSize int64   `json:"totalIndexSize" bson:"totalIndexSize"`
+}
+
+// GET /metrics/mongo (admin)
+// collStats of the results collection and, when the user may run it there, of the claims collection,
+// keyed by collection name. collStats is not cheap on large collections, so the result is cached for 5 minutes.
+func handleMongoMetrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cached, err := rds.Get(ctx, keyMongoMetrics).Result()
+	if err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(cached))
+		return
+	}
+	if !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	colls := make(map[string]collStats, 2)
+	st, err := runCollStats(ctx, colResult)
+	if err != nil {
+		http.Error(w, "mongo collStats error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	colls[colResult.Name()] = st
+	if st, err := runCollStats(ctx, colClaims); err == nil {
+		colls[colClaims.Name()] = st
+	} else {
+		log.Printf("[metrics] collStats %s: %v", colClaims.Name(), err) // e.g. no permission: report the results only
+	}
+
+	out := map[string]any{
+		"collections": colls,
+		"computed_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	if bz, err := json.Marshal(out); err == nil {
+		_ = rds.Set(ctx, keyMongoMetrics, string(bz), mongoMetricsTTL).Err()
+	}
+	writeJSON(w, out)
+}
+
+func runCollStats(ctx context.Context, coll *mongo.Collection) (collStats, error) {
+	var st collStats
+	err := coll.Database().RunCommand(ctx, bson.D{{Key: "collStats", Value: coll.Name()}}).Decode(&st)
+	return st, err
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// parseRedisInfo turns INFO output ("key:value" lines, "# Section" headers) into a map
+func parseRedisInfo(info string) map[string]string {
+	out := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// keyspaceCounts parses a keyspace line value ("keys=123,expires=45,avg_ttl=0") into keys and expires
+func keyspaceCounts(v string) (keys, expires int64) {
+	for _, kv := range strings.Split(v, ",") {
+		k, n, _ := strings.Cut(kv, "=")
+		switch k {
+		case "keys":
+			keys, _ = strconv.ParseInt(n, 10, 64)
+		case "expires":
+			expires, _ = strconv.ParseInt(n, 10, 64)
+		}
+	}
+	return keys, expires
+}
+
+// /metrics/redis
+// Memory usage and key count of the configured Redis DB (INFO memory keyspace), for capacity alerts
+// without Prometheus. maxmemory_human is "0B" when Redis has no memory limit.
+func handleRedisMetrics(w http.ResponseWriter, r *http.Request) {
+	info, err := rds.Info(r.Context(), "memory", "keyspace").Result()
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	m := parseRedisInfo(info)
+	keys, expires := keyspaceCounts(m["db"+strconv.Itoa(cfg.RedisDB)]) // absent when the DB is empty
+	writeJSON(w, map[string]any{
+		"used_memory_human":      m["used_memory_human"],
+		"used_memory_peak_human": m["used_memory_peak_human"],
+		"maxmemory_human":        m["maxmemory_human"],
+		"db":                     cfg.RedisDB,
+		"keys":                   keys,
+		"expires":                expires,
+	})
+}
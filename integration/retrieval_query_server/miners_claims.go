@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"storagestats/pkg/model"
+)
+
+// minerClaim is the claims document subset returned by /miners/<miner_addr>/claims
+type minerClaim struct {
+	ClaimID    int64  `bson:"claim_id"`
+	ClientAddr string `bson:"client_addr"`
+	DataCID    string `bson:"data_cid"`
+	Size       int64  `bson:"size"`
+	TermStart  int64  `bson:"term_start"`
+	TermMax    int64  `bson:"term_max"`
+}
+
+// handleMinerPaths routes /miners/<miner_addr>/...
+func handleMinerPaths(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/miners/")
+	switch {
+	case strings.HasSuffix(rest, "/claims"):
+		handleMinerClaims(w, r)
+	case strings.HasSuffix(rest, "/clients"):
+		handleMinerClients(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// GET /miners/<miner_addr>/claims?page=&page_size=&active_only=
+// Claims of one miner (soft-deleted claims excluded), newest term_start first. active_only=true keeps
+// the claims whose term_start + term_max is after meta:chain:head_epoch.
+func handleMinerClaims(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	miner, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/miners/"), "/claims")
+	if !ok || miner == "" || strings.Contains(miner, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	q := r.URL.Query()
+	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
+	activeOnly := false
+	if s := q.Get("active_only"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, "active_only must be true or false", http.StatusBadRequest)
+			return
+		}
+		activeOnly = b
+	}
+
+	filter := activeClaims(bson.M{"miner_addr": miner})
+	if activeOnly {
+		filter["$expr"] = bson.M{"$gt": bson.A{bson.M{"$add": bson.A{"$term_start", "$term_max"}}, headEpoch(ctx)}}
+	}
+	total, err := colClaims.CountDocuments(ctx, filter)
+	if err != nil {
+		http.Error(w, "mongo count error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	cur, err := colClaims.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "term_start", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page-1)*pageSize)).
+		SetLimit(int64(pageSize)).
+		SetProjection(bson.M{"claim_id": 1, "client_addr": 1, "data_cid": 1, "size": 1, "term_start": 1, "term_max": 1}))
+	if err != nil {
+		http.Error(w, "mongo find error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var claims []minerClaim
+	if err := cur.All(ctx, &claims); err != nil {
+		http.Error(w, "mongo decode error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]map[string]any, 0, len(claims))
+	for _, c := range claims {
+		items = append(items, map[string]any{
+			"claim_id":        c.ClaimID,
+			"data_cid":        c.DataCID,
+			"size":            c.Size,
+			"client_addr":     c.ClientAddr,
+			"term_start_time": model.EpochToTime64(c.TermStart).Format(time.RFC3339),
+			"expires_at":      model.EpochToTime64(c.TermStart + c.TermMax).Format(time.RFC3339),
+		})
+	}
+	setTotalHeader(w, total)
+	writeJSONStream(w, map[string]any{
+		"miner_addr":  miner,
+		"page":        page,
+		"page_size":   pageSize,
+		"active_only": activeOnly,
+		"total":       total,
+	}, items)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GET /miners/<miner_addr>/clients?page=&page_size=
+// Clients that had this miner checked, most checks first, from idx:miner:clients:<miner_addr>. The HTTP
+// success rate of each client on this miner is read from the page's stats:client:<addr> docs.
+func handleMinerClients(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	miner, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/miners/"), "/clients")
+	if !ok || miner == "" || strings.Contains(miner, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	q := r.URL.Query()
+	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
+	start := int64((page - 1) * pageSize)
+
+	pipe := rds.Pipeline()
+	totalCmd := pipe.ZCard(ctx, zsetMinerClients+miner)
+	zCmd := pipe.ZRevRangeWithScores(ctx, zsetMinerClients+miner, start, start+int64(pageSize)-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	zs := zCmd.Val()
+
+	var docs []*redis.StringCmd
+	if len(zs) > 0 {
+		pipe = rds.Pipeline()
+		docs = make([]*redis.StringCmd, len(zs))
+		for i, z := range zs {
+			docs[i] = pipe.Get(ctx, keyClientPrefix+z.Member.(string))
+		}
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	v := apiVersion(r)
+	items := make([]map[string]any, 0, len(zs))
+	for i, z := range zs {
+		client, _ := z.Member.(string)
+		it := map[string]any{
+			"client_addr":  client,
+			"total_checks": int64(z.Score),
+		}
+		var list []ClientMinerItem
+		if val, err := docs[i].Result(); err == nil && json.Unmarshal([]byte(val), &list) == nil {
+			for _, cm := range list {
+				if cm.MinerAddr == miner {
+					it["success_rate_http"] = rateValue(v, cm.SuccessRateHTTP)
+					break
+				}
+			}
+		}
+		items = append(items, it)
+	}
+
+	setTotalHeader(w, totalCmd.Val())
+	writeJSONStream(w, map[string]any{
+		"miner_addr": miner,
+		"page":       page,
+		"page_size":  pageSize,
+		"total":      totalCmd.Val(),
+	}, items)
+}
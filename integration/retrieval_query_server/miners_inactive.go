@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type inactiveMiner struct {
+	id          string
+	lastChecked time.Time // zero = never recorded
+	rate        float64
+}
+
+// /miners/inactive?days=7&page=&page_size=
+// Miners of idx:miners:http whose newest HTTP check (last_checked_at) is older than N days or unknown,
+// stalest first. Walks the ZSET and the miner docs in batches, so it is meant for schedulers, not dashboards.
+func handleMinersInactive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	days, ok := parseDays(q.Get("days"), 7)
+	if !ok {
+		http.Error(w, "days must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
+	v := apiVersion(r)
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -days)
+	miners, err := findInactiveMiners(ctx, cutoff)
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	total := int64(len(miners))
+	start := (page - 1) * pageSize
+	if start > len(miners) {
+		start = len(miners)
+	}
+	end := start + pageSize
+	if end > len(miners) {
+		end = len(miners)
+	}
+	items := make([]map[string]any, 0, end-start)
+	for _, m := range miners[start:end] {
+		it := map[string]any{
+			"miner_id":          m.id,
+			"last_checked_at":   nil,
+			"success_rate_http": rateValue(v, m.rate),
+		}
+		if !m.lastChecked.IsZero() {
+			it["last_checked_at"] = m.lastChecked.Format(time.RFC3339)
+		}
+		items = append(items, it)
+	}
+
+	setTotalHeader(w, total)
+	writeJSONStream(w, map[string]any{
+		"page":      page,
+		"page_size": pageSize,
+		"days":      days,
+		"cutoff":    cutoff.Format(time.RFC3339),
+		"total":     total,
+	}, items)
+}
+
+// findInactiveMiners returns the ranked miners not checked since cutoff, never-checked first, then oldest first
+func findInactiveMiners(ctx context.Context, cutoff time.Time) ([]inactiveMiner, error) {
+	const batch = 1000
+	var out []inactiveMiner
+
+	for start := int64(0); ; start += batch {
+		ids, err := rds.ZRange(ctx, zsetMinerHTTP, start, start+batch-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		pipe := rds.Pipeline()
+		cmds := make([]*redis.StringCmd, len(ids))
+		for i, id := range ids {
+			cmds[i] = pipe.Get(ctx, keyMinerPrefix+id)
+		}
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		for i, c := range cmds {
+			val, err := c.Result()
+			if err != nil {
+				continue // doc expired: the next cron run drops the miner from the ZSET too
+			}
+			var rd RateDoc
+			if json.Unmarshal([]byte(val), &rd) != nil {
+				continue
+			}
+			m := inactiveMiner{id: ids[i], rate: rd.SuccessRateHTTP}
+			if t, err := time.Parse(time.RFC3339, rd.LastCheckedAt); err == nil {
+				m.lastChecked = t
+			}
+			if m.lastChecked.IsZero() || m.lastChecked.Before(cutoff) {
+				out = append(out, m)
+			}
+		}
+		if len(ids) < batch {
+			break
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].lastChecked.Equal(out[j].lastChecked) {
+			return out[i].lastChecked.Before(out[j].lastChecked)
+		}
+		return out[i].id < out[j].id
+	})
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type missingMiner struct {
+	MinerID    string `bson:"_id" json:"miner_id"`
+	ClaimCount int64  `bson:"claim_count" json:"claim_count"`
+}
+
+// /miners/missing?page=&page_size=
+// Miners that have active claims but no entry in idx:miners:http, i.e. never successfully probed.
+// Sorted by claim count (desc) so the biggest un-probed providers come first.
+func handleMinersMissing(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
+
+	missing, err := loadMissingMiners(ctx)
+	if err != nil {
+		http.Error(w, "missing miners error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	total := len(missing)
+	start := (page - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+	writeJSONStream(w, map[string]any{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	}, missing[start:end])
+}
+
+// loadMissingMiners groups active claims by miner_addr and keeps the miners without a ZSET score
+func loadMissingMiners(ctx context.Context) ([]missingMiner, error) {
+	cur, err := colClaims.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: activeClaims(bson.M{"miner_addr": bson.M{"$nin": bson.A{nil, ""}}})}},
+		{{Key: "$group", Value: bson.M{"_id": "$miner_addr", "claim_count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "claim_count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var miners []missingMiner
+	if err := cur.All(ctx, &miners); err != nil {
+		return nil, err
+	}
+
+	pipe := rds.Pipeline()
+	cmds := make([]*redis.FloatCmd, len(miners))
+	for i, m := range miners {
+		cmds[i] = pipe.ZScore(ctx, zsetMinerHTTP, m.MinerID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	out := make([]missingMiner, 0)
+	for i, cmd := range cmds {
+		if errors.Is(cmd.Err(), redis.Nil) {
+			out = append(out, miners[i])
+		}
+	}
+	return out, nil
+}
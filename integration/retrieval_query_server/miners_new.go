@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const zsetFirstSeen = "idx:miners:first_seen" // score = unix time of the miner's first aggregation (ZADD NX)
+
+// /miners/new?days=7&page=&page_size=
+// Miners first indexed within the last N days (default 7), newest first. Served from idx:miners:first_seen only.
+func handleMinersNew(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	days := 7
+	if v := q.Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "days must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
+
+	now := time.Now().Unix()
+	min := strconv.FormatInt(now-int64(days)*86400, 10)
+	max := strconv.FormatInt(now, 10)
+
+	zs, err := rds.ZRevRangeByScoreWithScores(ctx, zsetFirstSeen, &redis.ZRangeBy{
+		Min: min, Max: max, Offset: int64((page - 1) * pageSize), Count: int64(pageSize),
+	}).Result()
+	if err != nil {
+		http.Error(w, "redis zset error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	total, err := rds.ZCount(ctx, zsetFirstSeen, min, max).Result()
+	if err != nil {
+		http.Error(w, "redis zset error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]map[string]any, 0, len(zs))
+	for _, z := range zs {
+		items = append(items, map[string]any{
+			"miner_id":   z.Member,
+			"first_seen": time.Unix(int64(z.Score), 0).UTC().Format(time.RFC3339),
+		})
+	}
+	writeJSONStream(w, map[string]any{
+		"page":      page,
+		"page_size": pageSize,
+		"days":      days,
+		"total":     total,
+	}, items)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+const maxOverlapMiners = 200
+
+// sharedMiner is a miner serving both clients, with each client's HTTP success rate on it
+type sharedMiner struct {
+	MinerAddr string
+	RateA     float64
+	RateB     float64
+}
+
+// minerOverlap intersects the miner sets of two clients; shared is sorted by miner address
+func minerOverlap(a, b []ClientMinerItem) (shared []sharedMiner, union int) {
+	rateA := make(map[string]float64, len(a))
+	for _, it := range a {
+		rateA[it.MinerAddr] = it.SuccessRateHTTP
+	}
+	union = len(rateA)
+	seenB := make(map[string]struct{}, len(b))
+	for _, it := range b {
+		if _, dup := seenB[it.MinerAddr]; dup {
+			continue
+		}
+		seenB[it.MinerAddr] = struct{}{}
+		if ra, ok := rateA[it.MinerAddr]; ok {
+			shared = append(shared, sharedMiner{MinerAddr: it.MinerAddr, RateA: ra, RateB: it.SuccessRateHTTP})
+		} else {
+			union++
+		}
+	}
+	sort.Slice(shared, func(i, j int) bool { return shared[i].MinerAddr < shared[j].MinerAddr })
+	return shared, union
+}
+
+// /miners/overlap?client_a=f1abc&client_b=f1def
+// Providers shared by two clients (from their stats:client:<addr> lists, read with one MGET): intersection
+// and union sizes, Jaccard similarity and up to 200 shared miners with both clients' HTTP success rates.
+func handleMinersOverlap(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	clientA, clientB := q.Get("client_a"), q.Get("client_b")
+	if clientA == "" || clientB == "" {
+		http.Error(w, "client_a and client_b are required", http.StatusBadRequest)
+		return
+	}
+	v := apiVersion(r)
+
+	vals, err := rds.MGet(r.Context(), keyClientPrefix+clientA, keyClientPrefix+clientB).Result()
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	lists := make([][]ClientMinerItem, 2)
+	for i, addr := range []string{clientA, clientB} {
+		s, ok := vals[i].(string)
+		if !ok {
+			http.Error(w, "client not found: "+addr, http.StatusNotFound)
+			return
+		}
+		if err := json.Unmarshal([]byte(s), &lists[i]); err != nil {
+			http.Error(w, "decode error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	shared, union := minerOverlap(lists[0], lists[1])
+	jaccard := 0.0
+	if union > 0 {
+		jaccard = float64(len(shared)) / float64(union)
+	}
+	capped := shared
+	if len(capped) > maxOverlapMiners {
+		capped = capped[:maxOverlapMiners]
+	}
+	items := make([]map[string]any, 0, len(capped))
+	for _, m := range capped {
+		items = append(items, map[string]any{
+			"miner_addr":          m.MinerAddr,
+			"success_rate_http_a": rateValue(v, m.RateA),
+			"success_rate_http_b": rateValue(v, m.RateB),
+		})
+	}
+	writeJSONStream(w, map[string]any{
+		"client_a":          clientA,
+		"client_b":          clientB,
+		"intersection_size": len(shared),
+		"union_size":        union,
+		"jaccard":           jaccard,
+		"truncated":         len(shared) > maxOverlapMiners,
+	}, items)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	keySectorCountPrefix = "cache:sectors:" // cache:sectors:<miner_id> (cached /miners/sector-count result)
+	sectorCountTTL       = 30 * time.Minute
+)
+
+type SectorCount struct {
+	MinerAddr   string `json:"miner_addr" bson:"_id"`
+	SectorCount int64  `json:"sector_count" bson:"sector_count"`
+	ClaimCount  int64  `json:"claim_count" bson:"claim_count"`
+	TotalBytes  int64  `json:"total_bytes" bson:"total_bytes"`
+}
+
+// /miners/sector-count?miner_addr=f01234
+// Number of distinct sectors the miner has (active) claims in, with claim count and bytes.
+// Cached for 30 minutes; claims only change when the claims ingester runs.
+func handleMinersSectorCount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	miner := r.URL.Query().Get("miner_addr")
+	if miner == "" {
+		http.Error(w, "miner_addr is required", http.StatusBadRequest)
+		return
+	}
+
+	cached, err := rds.Get(ctx, keySectorCountPrefix+miner).Result()
+	if err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(cached))
+		return
+	}
+	if !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sc, err := computeSectorCount(ctx, miner)
+	if err != nil {
+		http.Error(w, "sector count error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if sc.ClaimCount == 0 {
+		http.Error(w, "no claims for miner", http.StatusNotFound)
+		return
+	}
+	if bz, err := json.Marshal(sc); err == nil {
+		_ = rds.Set(ctx, keySectorCountPrefix+miner, string(bz), sectorCountTTL).Err()
+	}
+	writeJSON(w, sc)
+}
+
+func computeSectorCount(ctx context.Context, miner string) (SectorCount, error) {
+	cur, err := colClaims.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: activeClaims(bson.M{"miner_addr": miner})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$miner_addr",
+			"sectors":     bson.M{"$addToSet": "$sector"},
+			"claim_count": bson.M{"$sum": 1},
+			"total_bytes": bson.M{"$sum": "$size"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"sector_count": bson.M{"$size": "$sectors"},
+			"claim_count":  1,
+			"total_bytes":  1,
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return SectorCount{}, err
+	}
+	defer cur.Close(ctx)
+
+	var out []SectorCount
+	if err := cur.All(ctx, &out); err != nil {
+		return SectorCount{}, err
+	}
+	if len(out) == 0 {
+		return SectorCount{MinerAddr: miner}, nil
+	}
+	return out[0], nil
+}
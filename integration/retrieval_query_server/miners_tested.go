@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// handleMinersByLastTested serves /miners?sort_by=last_tested_at[&order=asc] from idx:miners:last_tested:
+// most recently tested first, or stalest first with order=asc. Miners never tested are not in the ZSET.
+func handleMinersByLastTested(w http.ResponseWriter, r *http.Request, page, pageSize int, withVolume bool) {
+	ctx := r.Context()
+	start := int64((page - 1) * pageSize)
+	end := start + int64(pageSize) - 1
+
+	var ids []string
+	var err error
+	switch r.URL.Query().Get("order") {
+	case "", "desc":
+		ids, err = rds.ZRevRange(ctx, zsetMinerTested, start, end).Result()
+	case "asc":
+		ids, err = rds.ZRange(ctx, zsetMinerTested, start, end).Result()
+	default:
+		http.Error(w, "order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "redis zset error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	items, err := minerItems(ctx, ids, apiVersion(r), true)
+	if err == nil && withVolume {
+		err = attachDataVolume(ctx, items)
+	}
+	if err != nil {
+		http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	total, _ := rds.ZCard(ctx, zsetMinerTested).Result()
+	setTotalHeader(w, total)
+	writeJSONStream(w, map[string]any{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	}, items)
+}
+
+// /miners/stale?hours=48&page=&page_size=
+// Miners whose newest HTTP check is older than N hours, stalest first: one ZRANGEBYSCORE on
+// idx:miners:last_tested. Unlike /miners/inactive it does not list miners that were never tested.
+func handleMinersStale(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	hours := 48
+	if s := q.Get("hours"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			http.Error(w, "hours must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		hours = n
+	}
+	page, pageSize := parsePage(q.Get("page"), q.Get("page_size"))
+
+	cutoff := time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
+	// Exclusive upper bound: a miner tested exactly at the cutoff is not stale
+	maxScore := "(" + strconv.FormatInt(cutoff.Unix(), 10)
+	ids, err := rds.ZRangeByScore(ctx, zsetMinerTested, &redis.ZRangeBy{
+		Min: "-inf", Max: maxScore, Offset: int64((page - 1) * pageSize), Count: int64(pageSize),
+	}).Result()
+	if err != nil {
+		http.Error(w, "redis zset error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	items, err := minerItems(ctx, ids, apiVersion(r), true)
+	if err != nil {
+		http.Error(w, "redis get error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	total, _ := rds.ZCount(ctx, zsetMinerTested, "-inf", maxScore).Result()
+	setTotalHeader(w, total)
+	writeJSONStream(w, map[string]any{
+		"page":      page,
+		"page_size": pageSize,
+		"hours":     hours,
+		"cutoff":    cutoff.Format(time.RFC3339),
+		"total":     total,
+	}, items)
+}
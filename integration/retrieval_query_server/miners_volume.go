@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const keyVolumePrefix = "vol:miner:" // vol:miner:<miner_id> (DataVolume JSON, written by the cron)
+
+type DataVolume struct {
+	MinerAddr     string `json:"miner_addr" bson:"_id"`
+	TotalBytes    int64  `json:"total_bytes" bson:"total_bytes"`
+	ClaimCount    int64  `json:"claim_count" bson:"claim_count"`
+	MeanPieceSize int64  `json:"mean_piece_size" bson:"-"`
+}
+
+// dataVolumePipeline sums the (active) claims per miner_addr
+func dataVolumePipeline(filter bson.M) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: activeClaims(filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$miner_addr",
+			"total_bytes": bson.M{"$sum": "$size"},
+			"claim_count": bson.M{"$sum": 1},
+		}}},
+	}
+}
+
+func (d *DataVolume) fillMean() {
+	if d.ClaimCount > 0 {
+		d.MeanPieceSize = d.TotalBytes / d.ClaimCount
+	}
+}
+
+// /miners/data-volume?miner_addr=f01234
+// Total bytes of the miner's (active) claims, straight from MongoDB.
+func handleMinersDataVolume(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	miner := r.URL.Query().Get("miner_addr")
+	if miner == "" {
+		http.Error(w, "miner_addr is required", http.StatusBadRequest)
+		return
+	}
+
+	cur, err := colClaims.Aggregate(ctx, dataVolumePipeline(bson.M{"miner_addr": miner}))
+	if err != nil {
+		http.Error(w, "mongo aggregate error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var out []DataVolume
+	if err := cur.All(ctx, &out); err != nil {
+		http.Error(w, "mongo decode error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(out) == 0 {
+		http.Error(w, "no claims for miner", http.StatusNotFound)
+		return
+	}
+	vol := out[0]
+	vol.fillMean()
+	writeJSON(w, vol)
+}
+
+// computeAndStoreMinerVolume refreshes vol:miner:<id> for every miner with claims (cron)
+func computeAndStoreMinerVolume(ctx context.Context) (n int, err error) {
+	ctx, span := startSpan(ctx, "cron.aggregate miner volume", attribute.String("db_name", cfg.MongoDB))
+	defer func() {
+		span.SetAttributes(attribute.Int("result_count", n))
+		endSpan(span, err)
+	}()
+
+	cur, err := colClaims.Aggregate(ctx, dataVolumePipeline(bson.M{}), cronAggregateOptions())
+	if err != nil {
+		return 0, aggErr("miner volume", err)
+	}
+	defer cur.Close(ctx)
+
+	pipe := newBatchPipe()
+	for cur.Next(ctx) {
+		var vol DataVolume
+		if err := cur.Decode(&vol); err != nil {
+			return 0, err
+		}
+		if vol.MinerAddr == "" {
+			continue
+		}
+		vol.fillMean()
+		val, err := marshalDoc(keyVolumePrefix+vol.MinerAddr, vol)
+		if err != nil {
+			return 0, err
+		}
+		pipe.Set(ctx, keyVolumePrefix+vol.MinerAddr, val, redisTTL)
+		n++
+		if err := pipe.maybeFlush(ctx); err != nil {
+			return 0, fmt.Errorf("miner volume write-back failed after %d miners: %w", n, err)
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return 0, aggErr("miner volume", err)
+	}
+	if err := pipe.flush(ctx); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// attachDataVolume adds "data_volume" (or null if the cron has no volume for the miner) to each item
+// with a single MGET
+func attachDataVolume(ctx context.Context, items []map[string]any) error {
+	if len(items) == 0 {
+		return nil
+	}
+	keys := make([]string, len(items))
+	for i, it := range items {
+		id, _ := it["miner_id"].(string)
+		keys[i] = keyVolumePrefix + id
+	}
+	vals, err := rds.MGet(ctx, keys...).Result()
+	if err != nil {
+		return err // missing keys come back as nil values, not redis.Nil
+	}
+	for i, it := range items {
+		it["data_volume"] = nil
+		s, ok := vals[i].(string)
+		if !ok {
+			continue
+		}
+		var vol DataVolume
+		if json.Unmarshal([]byte(s), &vol) == nil {
+			it["data_volume"] = vol
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/sync/errgroup"
+)
+
+const providerJoinTTL = 10 * time.Minute
+
+// activeClaims is the default claims filter: soft-deleted claims (provider lost power) are hidden
+func activeClaims(filter bson.M) bson.M {
+	filter["deleted_at"] = nil
+	return filter
+}
+
+// providerJoin is the cached, version-independent form of a /providers response
+type providerJoin struct {
+	Stats      *RateDoc `json:"stats"`
+	ClaimCount int64    `json:"claim_count"`
+	TotalBytes int64    `json:"total_bytes"`
+}
+
+// /providers?miner_addr=f01234
+// Joins the miner's retrieval stats (Redis) with its claims summary (MongoDB). Either side may be
+// missing: stats is null for miners that were never probed. Cached for 10 minutes.
+func handleProviders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	miner := r.URL.Query().Get("miner_addr")
+	if miner == "" {
+		http.Error(w, "miner_addr is required", http.StatusBadRequest)
+		return
+	}
+
+	var pj providerJoin
+	cached, err := rds.Get(ctx, keyProviderJoin+miner).Result()
+	if err == nil && json.Unmarshal([]byte(cached), &pj) == nil {
+		writeProvider(w, r, miner, pj)
+		return
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	pj, err = loadProviderJoin(ctx, miner)
+	if err != nil {
+		http.Error(w, "provider lookup error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if pj.Stats == nil && pj.ClaimCount == 0 {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return
+	}
+	if bz, err := json.Marshal(pj); err == nil {
+		_ = rds.Set(ctx, keyProviderJoin+miner, string(bz), providerJoinTTL).Err()
+	}
+	writeProvider(w, r, miner, pj)
+}
+
+// loadProviderJoin fetches the Redis doc and the claims aggregation concurrently
+func loadProviderJoin(ctx context.Context, miner string) (providerJoin, error) {
+	var pj providerJoin
+	g, gctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		val, err := rds.Get(gctx, keyMinerPrefix+miner).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var rd RateDoc
+		if err := json.Unmarshal([]byte(val), &rd); err != nil {
+			return err
+		}
+		pj.Stats = &rd
+		return nil
+	})
+
+	g.Go(func() error {
+		cur, err := colClaims.Aggregate(gctx, mongo.Pipeline{
+			{{Key: "$match", Value: activeClaims(bson.M{"miner_addr": miner})}},
+			{{Key: "$group", Value: bson.M{
+				"_id":         nil,
+				"claim_count": bson.M{"$sum": 1},
+				"total_bytes": bson.M{"$sum": "$size"},
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		defer cur.Close(gctx)
+		var out []struct {
+			ClaimCount int64 `bson:"claim_count"`
+			TotalBytes int64 `bson:"total_bytes"`
+		}
+		if err := cur.All(gctx, &out); err != nil {
+			return err
+		}
+		if len(out) > 0 {
+			pj.ClaimCount, pj.TotalBytes = out[0].ClaimCount, out[0].TotalBytes
+		}
+		return nil
+	})
+
+	return pj, g.Wait()
+}
+
+func writeProvider(w http.ResponseWriter, r *http.Request, miner string, pj providerJoin) {
+	var stats any
+	if pj.Stats != nil {
+		stats = minerItem(miner, *pj.Stats, apiVersion(r))
+	}
+	writeJSON(w, map[string]any{
+		"miner_addr": miner,
+		"stats":      stats,
+		"claims": map[string]any{
+			"claim_count": pj.ClaimCount,
+			"total_bytes": pj.TotalBytes,
+		},
+	})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyConnLimit      = "ratelimit:connectivity:" // ratelimit:connectivity:<miner_id>, one probe per miner per minute
+	connLimitWindow   = time.Minute
+	connDialTimeout   = 5 * time.Second
+	maxConnMultiaddrs = 16
+)
+
+// connResult is the probe outcome of one multiaddr
+type connResult struct {
+	Multiaddr string `json:"multiaddr"`
+	Reachable bool   `json:"reachable"`
+	LatencyMS *int64 `json:"latency_ms,omitempty"` // TCP handshake time, only when reachable
+	Error     string `json:"error,omitempty"`
+}
+
+// GET /providers/<miner_addr>/connectivity
+// Dials every multiaddr of stats:provider:<miner_id> over TCP (5s timeout each, in parallel) from the query
+// server's network position. Limited to one probe per miner per minute; private and loopback addresses are
+// never dialled.
+func handleProviderConnectivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	miner, ok := minerFromPath(r, "/connectivity")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	val, err := rds.Get(ctx, keyProviderPrefix+miner).Result()
+	if errors.Is(err, redis.Nil) {
+		http.Error(w, "no multiaddrs for miner", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var pd ProviderDoc
+	if err := json.Unmarshal([]byte(val), &pd); err != nil {
+		http.Error(w, "decode error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	first, err := rds.SetNX(ctx, keyConnLimit+miner, time.Now().UTC().Format(time.RFC3339), connLimitWindow).Result()
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !first {
+		retry := connLimitWindow
+		if ttl, err := rds.TTL(ctx, keyConnLimit+miner).Result(); err == nil && ttl > 0 {
+			retry = ttl
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(retry.Round(time.Second)/time.Second)))
+		http.Error(w, "connectivity of this miner was probed less than a minute ago", http.StatusTooManyRequests)
+		return
+	}
+
+	addrs := pd.Multiaddrs
+	if len(addrs) > maxConnMultiaddrs {
+		addrs = addrs[:maxConnMultiaddrs]
+	}
+	items := make([]connResult, len(addrs))
+	var wg sync.WaitGroup
+	for i, a := range addrs {
+		wg.Add(1)
+		go func(i int, a string) {
+			defer wg.Done()
+			items[i] = probeMultiaddr(ctx, a)
+		}(i, a)
+	}
+	wg.Wait()
+
+	writeJSONStream(w, map[string]any{
+		"miner_addr":  miner,
+		"observed_at": pd.ObservedAt,
+		"probed_at":   time.Now().UTC().Format(time.RFC3339),
+	}, items)
+}
+
+// probeMultiaddr opens (and closes) one TCP connection to a multiaddr
+func probeMultiaddr(ctx context.Context, addr string) connResult {
+	res := connResult{Multiaddr: addr}
+	ma, err := multiaddr.NewMultiaddr(addr)
+	if err != nil {
+		res.Error = "invalid multiaddr: " + err.Error()
+		return res
+	}
+	network, hostPort, err := manet.DialArgs(ma)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if !strings.HasPrefix(network, "tcp") {
+		res.Error = "not a TCP address (" + network + ")"
+		return res
+	}
+	if !manet.IsPublicAddr(ma) {
+		res.Error = "not a public address, not dialled"
+		return res
+	}
+
+	dctx, cancel := context.WithTimeout(ctx, connDialTimeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := (&net.Dialer{}).DialContext(dctx, network, hostPort)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	latency := time.Since(start).Milliseconds()
+	_ = conn.Close()
+	res.Reachable = true
+	res.LatencyMS = &latency
+	return res
+}
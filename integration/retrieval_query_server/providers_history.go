@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	snapshotDateLayout = "20060102"
+	maxHistoryDays     = 90 // = minerSnapshotTTL
+)
+
+// minerSnapshotKey is the daily copy of stats:miner:<miner_id> written by the cron: stats:miner:<miner_id>:<YYYYMMDD>
+func minerSnapshotKey(miner, date string) string {
+	return keyMinerPrefix + miner + ":" + date
+}
+
+// historyPoint is one day of /providers/<miner_addr>/history; the pointers are null for days without a snapshot
+type historyPoint struct {
+	Date          string   `json:"date"`
+	HTTPRate      *float64 `json:"http_rate"`
+	GraphsyncRate *float64 `json:"graphsync_rate"`
+	BitswapRate   *float64 `json:"bitswap_rate"`
+	TotalChecks   *int64   `json:"total_checks"`
+	IsLatest      bool     `json:"is_latest,omitempty"`
+}
+
+// GET /providers/<miner_addr>/history?days=30
+// Daily success rates of a miner from its stats:miner:<miner_id>:<YYYYMMDD> snapshots (one MGET), oldest
+// first and ending today (UTC). Days without a snapshot have null values; the last entry has
+// is_latest=true when today's snapshot exists.
+func handleProviderHistory(w http.ResponseWriter, r *http.Request) {
+	miner, ok := minerFromPath(r, "/history")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	days, ok := parseDays(r.URL.Query().Get("days"), 30)
+	if !ok || days > maxHistoryDays {
+		http.Error(w, "days must be between 1 and 90", http.StatusBadRequest)
+		return
+	}
+
+	today := time.Now().UTC()
+	dates := make([]time.Time, days)
+	keys := make([]string, days)
+	for i := range dates {
+		dates[i] = today.AddDate(0, 0, i-days+1)
+		keys[i] = minerSnapshotKey(miner, dates[i].Format(snapshotDateLayout))
+	}
+	vals, err := rds.MGet(r.Context(), keys...).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	points := make([]historyPoint, days)
+	found := 0
+	for i, v := range vals {
+		points[i].Date = dates[i].Format("2006-01-02")
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var rd RateDoc
+		if json.Unmarshal([]byte(s), &rd) != nil {
+			continue
+		}
+		points[i].HTTPRate = &rd.SuccessRateHTTP
+		points[i].GraphsyncRate = &rd.SuccessRateGraphsync
+		points[i].BitswapRate = &rd.SuccessRateBitswap
+		points[i].TotalChecks = &rd.TotalHTTP
+		found++
+	}
+	if found == 0 {
+		http.Error(w, "no history for miner", http.StatusNotFound)
+		return
+	}
+	last := &points[len(points)-1]
+	last.IsLatest = last.HTTPRate != nil
+	writeJSON(w, map[string]any{
+		"miner_addr": miner,
+		"days":       days,
+		"items":      points,
+	})
+}
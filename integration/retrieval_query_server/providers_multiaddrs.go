@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// handleProviderPaths routes /providers/<miner_addr>/...
+func handleProviderPaths(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/providers/")
+	switch {
+	case strings.HasSuffix(rest, "/peerid"):
+		handleProviderPeerID(w, r)
+	case strings.HasSuffix(rest, "/multiaddrs"):
+		handleProviderMultiaddrs(w, r)
+	case strings.HasSuffix(rest, "/history"):
+		handleProviderHistory(w, r)
+	case strings.HasSuffix(rest, "/connectivity"):
+		handleProviderConnectivity(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// minerFromPath extracts <miner_addr> from /providers/<miner_addr><suffix>
+func minerFromPath(r *http.Request, suffix string) (string, bool) {
+	miner, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/providers/"), suffix)
+	if !ok || miner == "" || strings.Contains(miner, "/") {
+		return "", false
+	}
+	return miner, true
+}
+
+// GET /providers/<miner_addr>/multiaddrs
+// Multiaddrs reported by the miner's newest HTTP check that had any (stats:provider:<miner_id>, written by the cron)
+func handleProviderMultiaddrs(w http.ResponseWriter, r *http.Request) {
+	miner, ok := minerFromPath(r, "/multiaddrs")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	val, err := rds.Get(r.Context(), keyProviderPrefix+miner).Result()
+	if errors.Is(err, redis.Nil) {
+		http.Error(w, "no multiaddrs for miner", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var pd ProviderDoc
+	if err := json.Unmarshal([]byte(val), &pd); err != nil {
+		http.Error(w, "decode error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"miner_addr":  miner,
+		"multiaddrs":  pd.Multiaddrs,
+		"observed_at": pd.ObservedAt,
+	})
+}
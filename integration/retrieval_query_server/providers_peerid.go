@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	keyPeerIDPrefix = "cache:peerid:" // cache:peerid:<miner_id> (cached /providers/<miner_addr>/peerid result)
+	hashLastPeerIDs = "meta:peerids"  // hash miner_id -> last peer ID seen in the results (no TTL)
+	peerIDTTL       = time.Hour
+)
+
+type providerPeerID struct {
+	MinerAddr  string `json:"miner_addr"`
+	PeerID     string `json:"peer_id"`
+	LastSeenAt string `json:"last_seen_at"` // created_at of the newest result carrying the peer ID
+}
+
+// GET /providers/<miner_addr>/peerid
+// Peer ID of the miner's newest task result that has one (task.provider.peer_id). Cached for 1 hour; a
+// warning is logged when a fresh lookup returns a different peer ID than the previous one.
+func handleProviderPeerID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	miner, ok := minerFromPath(r, "/peerid")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	key := keyPeerIDPrefix + miner
+	cached, err := rds.Get(ctx, key).Result()
+	if err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(cached))
+		return
+	}
+	if !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out, found, err := findLatestPeerID(ctx, miner)
+	if err != nil {
+		http.Error(w, "mongo find error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "no results for miner", http.StatusNotFound)
+		return
+	}
+
+	if prev, err := rds.HGet(ctx, hashLastPeerIDs, miner).Result(); err == nil && prev != out.PeerID {
+		log.Printf("[peerid] %s changed peer ID: %s -> %s", miner, prev, out.PeerID)
+	}
+	_ = rds.HSet(ctx, hashLastPeerIDs, miner, out.PeerID).Err()
+	if bz, err := json.Marshal(out); err == nil {
+		_ = rds.Set(ctx, key, string(bz), peerIDTTL).Err()
+	}
+	writeJSON(w, out)
+}
+
+// findLatestPeerID reads the newest result of the miner with a non-empty task.provider.peer_id
+func findLatestPeerID(ctx context.Context, miner string) (providerPeerID, bool, error) {
+	var doc struct {
+		Task struct {
+			Provider struct {
+				PeerID string `bson:"peer_id"`
+			} `bson:"provider"`
+		} `bson:"task"`
+		CreatedAt time.Time `bson:"created_at"`
+	}
+	err := colResult.FindOne(ctx,
+		bson.M{"task.provider.id": miner, "task.provider.peer_id": bson.M{"$gt": ""}},
+		options.FindOne().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetProjection(bson.M{"task.provider.peer_id": 1, "created_at": 1}),
+	).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return providerPeerID{}, false, nil
+	}
+	if err != nil {
+		return providerPeerID{}, false, err
+	}
+	return providerPeerID{
+		MinerAddr:  miner,
+		PeerID:     doc.Task.Provider.PeerID,
+		LastSeenAt: doc.CreatedAt.UTC().Format(time.RFC3339),
+	}, true, nil
+}
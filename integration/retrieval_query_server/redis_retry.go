@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// retryRedis runs fn up to REDIS_WRITE_RETRIES times, waiting REDIS_WRITE_BACKOFF_MS before the second
+// attempt and doubling the wait after each failure. Error replies from Redis (WRONGTYPE, OOM, ...) and a
+// cancelled context are returned at once: only connection-level failures are worth another try.
+func retryRedis(ctx context.Context, op string, fn func() error) error {
+	backoff := time.Duration(cfg.RedisBackoffMS) * time.Millisecond
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || !retryableRedisErr(err) || attempt >= cfg.RedisRetries {
+			return err
+		}
+		log.Printf("[redis] %s failed (attempt %d/%d), retrying in %s: %v", op, attempt, cfg.RedisRetries, backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func retryableRedisErr(err error) bool {
+	var rerr redis.Error
+	return !errors.As(err, &rerr) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// replayPipeline queues already executed commands on a fresh pipeline and runs them again, in order
+func replayPipeline(ctx context.Context, cmds []redis.Cmder) ([]redis.Cmder, error) {
+	pipe := rds.Pipeline()
+	for _, c := range cmds {
+		_ = pipe.Process(ctx, c)
+	}
+	return pipe.Exec(ctx)
+}
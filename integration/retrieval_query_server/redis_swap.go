@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	shadowSuffix = ":next" // marks the key a write-back fills before it replaces the live key (stats:client:<addr>:next)
+	swapChunk    = 256     // shadow/live pairs renamed per EVAL; bounds how long one script blocks Redis
+)
+
+// swapShadowScript renames every KEYS[i] (shadow) to KEYS[i+1] (live) in one script, so readers see either
+// the previous or the new version of every key of the chunk. Missing shadows are skipped: a retried swap
+// only renames what is left. Shadow and live key hash to different slots, so this needs a single-node
+// Redis (the query server does not support Cluster).
+var swapShadowScript = redis.NewScript(`
+local n = 0
+for i = 1, #KEYS, 2 do
+	if redis.call('EXISTS', KEYS[i]) == 1 then
+		redis.call('RENAME', KEYS[i], KEYS[i + 1])
+		n = n + 1
+	end
+end
+return n
+`)
+
+// swapShadowKeys replaces each live key with its shadow (live + shadowSuffix), swapChunk keys per script in
+// the order given, and returns how many keys were renamed. Each chunk is atomic, the whole list is not:
+// other commands run between chunks, so put index keys last to never point at a client not swapped yet.
+// RENAME keeps the shadow's TTL.
+func swapShadowKeys(ctx context.Context, live []string) (n int, err error) {
+	keys := make([]string, 0, 2*swapChunk)
+	for start := 0; start < len(live); start += swapChunk {
+		end := start + swapChunk
+		if end > len(live) {
+			end = len(live)
+		}
+		keys = keys[:0]
+		for _, k := range live[start:end] {
+			keys = append(keys, k+shadowSuffix, k)
+		}
+		var renamed int
+		err = retryRedis(ctx, "shadow key swap", func() (err error) {
+			renamed, err = swapShadowScript.Run(ctx, rds, keys).Int()
+			return err
+		})
+		n += renamed
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwapShadowKeys_Chunks(t *testing.T) {
+	useTestStores(t, false)
+	ctx := context.Background()
+
+	live := make([]string, 0, 2*swapChunk+3)
+	for i := 0; i < cap(live); i++ {
+		k := fmt.Sprintf("%s%d", keyClientPrefix, i)
+		require.NoError(t, rds.Set(ctx, k, "old", 0).Err())
+		if i%10 != 0 { // every 10th key has no shadow and keeps its value
+			require.NoError(t, rds.Set(ctx, k+shadowSuffix, "new", 0).Err())
+		}
+		live = append(live, k)
+	}
+
+	n, err := swapShadowKeys(ctx, live)
+	require.NoError(t, err)
+	assert.Equal(t, len(live)-(len(live)+9)/10, n)
+	for i, k := range live {
+		want := "new"
+		if i%10 == 0 {
+			want = "old"
+		}
+		assert.Equal(t, want, rds.Get(ctx, k).Val(), k)
+		assert.Zero(t, rds.Exists(ctx, k+shadowSuffix).Val(), k)
+	}
+
+	// nothing left to rename on a retry
+	n, err = swapShadowKeys(ctx, live)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 100
+	searchScanDepth    = 10000 // members visited per ZSET (ZSCAN COUNT 1000 x 10 calls), bounds latency
+)
+
+type searchHit struct {
+	kind  string // "miner" or "client"
+	id    string
+	score float64
+}
+
+// /search?q=f01&limit=10
+// Matches miner and client addresses at once: ZSCAN *q* on idx:miners:http and idx:clients:http concurrently,
+// merged and sorted by HTTP success rate (desc). Each ZSET is only scanned up to ~10,000 members, so
+// matches beyond that are missed on very large indexes.
+func handleSearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	term := q.Get("q")
+	if term == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	limit := defaultSearchLimit
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxSearchLimit {
+			http.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	v := apiVersion(r)
+	pattern := "*" + term + "*"
+
+	var miners, clients []redis.Z
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		miners, err = zscanBounded(gctx, zsetMinerHTTP, pattern, searchScanDepth)
+		return err
+	})
+	g.Go(func() (err error) {
+		clients, err = zscanBounded(gctx, zsetClientHTTP, pattern, searchScanDepth) // empty if the ZSET does not exist
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		http.Error(w, "redis zscan error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	hits := make([]searchHit, 0, len(miners)+len(clients))
+	for _, z := range miners {
+		hits = append(hits, searchHit{kind: "miner", id: z.Member.(string), score: z.Score})
+	}
+	for _, z := range clients {
+		hits = append(hits, searchHit{kind: "client", id: z.Member.(string), score: z.Score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].id < hits[j].id
+	})
+	total := len(hits)
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	items := make([]map[string]any, 0, len(hits))
+	for _, h := range hits {
+		items = append(items, map[string]any{
+			"type":              h.kind,
+			"id":                h.id,
+			"success_rate_http": rateValue(v, h.score),
+		})
+	}
+	writeJSONStream(w, map[string]any{
+		"q":     term,
+		"limit": limit,
+		"total": total,
+	}, items)
+}
+
+// zscanBounded is zscanAll that stops after roughly maxMembers members have been visited
+func zscanBounded(ctx context.Context, key, pattern string, maxMembers int) ([]redis.Z, error) {
+	const count = 1000
+	var cursor uint64
+	var matched []redis.Z
+	for visited := 0; visited < maxMembers; visited += count {
+		keys, next, err := rds.ZScan(ctx, key, cursor, pattern, count).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+1 < len(keys); i += 2 {
+			sc, _ := strconv.ParseFloat(keys[i+1], 64)
+			matched = append(matched, redis.Z{Member: keys[i], Score: sc})
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	return matched, nil
+}
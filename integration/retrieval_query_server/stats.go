@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// /stats
+// Summary of the served dataset: ranked miner and client counts, the last cron run and the composite
+// score weights, so clients can see how composite_score and sort_by=composite rank miners.
+func handleStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pipe := rds.Pipeline()
+	minersCmd := pipe.ZCard(ctx, zsetMinerHTTP)
+	clientsCmd := pipe.ZCard(ctx, zsetClientHTTP)
+	tsCmd := pipe.Get(ctx, keyLastCronRun)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"miners":            minersCmd.Val(),
+		"clients":           clientsCmd.Val(),
+		"last_cron_run":     tsCmd.Val(),
+		"composite_weights": cfg.Weights,
+	})
+}
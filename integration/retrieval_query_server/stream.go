@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sseTopMiners     = 20
+	sseHeartbeatTick = 30 * time.Second
+)
+
+var (
+	sseClients sync.Map     // chan []byte -> API version (int)
+	sseCount   atomic.Int64 // number of connected clients
+	sseLast    atomic.Value // map[int][]byte, last broadcast payload per API version (sent on connect)
+)
+
+// broadcastTopMiners serializes the current top miners and fans the payload out to all SSE clients.
+// Slow clients whose buffer is still full simply miss this update.
+func broadcastTopMiners(ctx context.Context) error {
+	ids, err := rds.ZRevRange(ctx, zsetMinerHTTP, 0, sseTopMiners-1).Result()
+	if err != nil {
+		return err
+	}
+	// One round-trip for all docs; expired keys come back as redis.Nil and are skipped
+	pipe := rds.Pipeline()
+	cmds := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.Get(ctx, keyMinerPrefix+id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	docs := make(map[string]RateDoc, len(ids))
+	for i, id := range ids {
+		val, err := cmds[i].Result()
+		if err != nil {
+			continue
+		}
+		var rd RateDoc
+		_ = json.Unmarshal([]byte(val), &rd)
+		docs[id] = rd
+	}
+
+	payloads := make(map[int][]byte, 2)
+	for _, v := range []int{1, 2} {
+		items := make([]map[string]any, 0, len(docs))
+		for _, id := range ids {
+			if rd, ok := docs[id]; ok {
+				items = append(items, minerItem(id, rd, v))
+			}
+		}
+		bz, err := json.Marshal(items)
+		if err != nil {
+			return err
+		}
+		payloads[v] = bz
+	}
+	sseLast.Store(payloads)
+
+	sseClients.Range(func(k, v any) bool {
+		select {
+		case k.(chan []byte) <- payloads[v.(int)]:
+		default:
+		}
+		return true
+	})
+	return nil
+}
+
+// /miners/stream
+// Server-Sent Events: one `data:` event (JSON array of the top miners) after every cron run,
+// plus a heartbeat event every 30s so that proxies keep the connection open.
+func handleMinersStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	if sseCount.Add(1) > int64(cfg.SSEMaxClients) {
+		sseCount.Add(-1)
+		http.Error(w, "too many stream clients", http.StatusServiceUnavailable)
+		return
+	}
+	defer sseCount.Add(-1)
+
+	v := apiVersion(r)
+	ch := make(chan []byte, 1)
+	sseClients.Store(ch, v)
+	defer sseClients.Delete(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// Send the latest snapshot right away so new clients don't wait for the next cron run
+	if last, ok := sseLast.Load().(map[int][]byte); ok {
+		fmt.Fprintf(w, "data: %s\n\n", last[v])
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(sseHeartbeatTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case bz := <-ch:
+			fmt.Fprintf(w, "data: %s\n\n", bz)
+			flusher.Flush()
+		case t := <-ticker.C:
+			fmt.Fprintf(w, "event: heartbeat\ndata: %q\n\n", t.UTC().Format(time.RFC3339))
+			flusher.Flush()
+		}
+	}
+}
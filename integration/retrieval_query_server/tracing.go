@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "retrieval_query_server"
+
+// tracer stays the global no-op tracer unless OTEL_EXPORTER_OTLP_ENDPOINT is set
+var tracer = otel.Tracer(serviceName)
+
+// initTracing installs an OTLP/HTTP exporter when cfg.OTELEndpoint is set. The exporter reads
+// OTEL_EXPORTER_OTLP_ENDPOINT (and the other OTEL_EXPORTER_OTLP_* variables) itself.
+func initTracing(ctx context.Context) {
+	if cfg.OTELEndpoint == "" {
+		return
+	}
+	exp, err := otlptracehttp.New(ctx)
+	if err != nil {
+		log.Fatalf("otel exporter: %v", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(tp)
+	tracer = tp.Tracer(serviceName)
+	log.Printf("otel tracing enabled, endpoint=%s", cfg.OTELEndpoint)
+}
+
+// withTracing wraps the handler in otelhttp only when tracing is enabled
+func withTracing(h http.Handler) http.Handler {
+	if cfg.OTELEndpoint == "" {
+		return h
+	}
+	return otelhttp.NewHandler(h, serviceName)
+}
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err (if any) and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
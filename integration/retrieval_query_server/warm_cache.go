@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const maxWarmCacheMiners = 1000
+
+// POST /admin/warm-cache {"miner_addrs": ["f01234", ...]}
+// Re-aggregates the listed miners one by one (WARM_CACHE_WORKERS at a time) and writes their Redis docs and
+// ZSET entries, e.g. right after a Redis restart instead of waiting for the next cron run. A failed miner
+// does not stop the others.
+func handleAdminWarmCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		MinerAddrs []string `json:"miner_addrs"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	miners := make([]string, 0, len(req.MinerAddrs))
+	seen := make(map[string]struct{}, len(req.MinerAddrs))
+	for _, m := range req.MinerAddrs {
+		if _, dup := seen[m]; m == "" || dup {
+			continue
+		}
+		seen[m] = struct{}{}
+		miners = append(miners, m)
+	}
+	if len(miners) == 0 || len(miners) > maxWarmCacheMiners {
+		http.Error(w, "miner_addrs must list 1 to 1000 miners", http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	var (
+		mu       sync.Mutex
+		warmed   int
+		notFound = []string{}
+		failed   = map[string]string{}
+	)
+	var g errgroup.Group
+	g.SetLimit(cfg.WarmWorkers)
+	for _, m := range miners {
+		m := m
+		g.Go(func() error {
+			n, err := refreshMiners(r.Context(), []string{m})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				failed[m] = err.Error()
+			case n == 0:
+				notFound = append(notFound, m) // no HTTP results for this miner
+			default:
+				warmed++
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	took := time.Since(start)
+	log.Printf("[admin] warm-cache: %d requested, %d warmed, %d not found, %d failed in %s",
+		len(miners), warmed, len(notFound), len(failed), took)
+	writeJSON(w, map[string]any{
+		"requested":   len(miners),
+		"warmed":      warmed,
+		"not_found":   notFound,
+		"failed":      failed,
+		"duration_ms": took.Milliseconds(),
+	})
+}
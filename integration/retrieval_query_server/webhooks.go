@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyWebhookPrefix   = "webhooks:"    // webhooks:<subscriber_id> (hash: url, miner_ids, min_rate_change, created_at)
+	setWebhooks        = "idx:webhooks" // set of subscriber ids
+	webhookRetries     = 3
+	webhookTimeout     = 10 * time.Second
+	maxWebhookMinerIDs = 1000
+)
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+type webhookSub struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	MinerIDs      []string `json:"miner_ids"`
+	MinRateChange float64  `json:"min_rate_change"`
+}
+
+// webhookChange is one miner entry of a webhook delivery
+type webhookChange struct {
+	MinerID string  `json:"miner_id"`
+	OldRate float64 `json:"old_rate"`
+	NewRate float64 `json:"new_rate"`
+	Change  float64 `json:"change"`
+}
+
+// POST   /miners/subscribe       {"url": "https://...", "miner_ids": ["f0123"], "min_rate_change": 0.05}
+// DELETE /miners/subscribe/<id>
+// After each cron run, subscribers receive the miners whose HTTP success rate moved by more than
+// min_rate_change. Subscriptions that still fail after 3 delivery attempts are removed.
+func handleMinersSubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	var sub webhookSub
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&sub); err != nil {
+		http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "url must be an absolute http(s) URL", http.StatusBadRequest)
+		return
+	}
+	if len(sub.MinerIDs) == 0 || len(sub.MinerIDs) > maxWebhookMinerIDs {
+		http.Error(w, fmt.Sprintf("miner_ids must contain 1..%d entries", maxWebhookMinerIDs), http.StatusBadRequest)
+		return
+	}
+	if sub.MinRateChange < 0 || sub.MinRateChange > 1 {
+		http.Error(w, "min_rate_change must be between 0 and 1", http.StatusBadRequest)
+		return
+	}
+
+	idb := make([]byte, 16)
+	if _, err := rand.Read(idb); err != nil {
+		http.Error(w, "id generation error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sub.ID = hex.EncodeToString(idb)
+	minerIDs, _ := json.Marshal(sub.MinerIDs)
+
+	pipe := rds.TxPipeline()
+	pipe.HSet(ctx, keyWebhookPrefix+sub.ID, map[string]any{
+		"url":             sub.URL,
+		"miner_ids":       string(minerIDs),
+		"min_rate_change": strconv.FormatFloat(sub.MinRateChange, 'f', -1, 64),
+		"created_at":      time.Now().UTC().Format(time.RFC3339),
+	})
+	pipe.SAdd(ctx, setWebhooks, sub.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(sub)
+}
+
+func handleMinersUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/miners/subscribe/")
+	if id == "" || strings.Contains(id, "/") {
+		http.Error(w, "subscription id is required", http.StatusBadRequest)
+		return
+	}
+	n, err := deleteWebhook(r.Context(), id)
+	if err != nil {
+		http.Error(w, "redis error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deleteWebhook(ctx context.Context, id string) (int64, error) {
+	pipe := rds.TxPipeline()
+	del := pipe.Del(ctx, keyWebhookPrefix+id)
+	pipe.SRem(ctx, setWebhooks, id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return del.Val(), nil
+}
+
+// loadWebhooks returns all subscriptions; broken hashes are skipped
+func loadWebhooks(ctx context.Context) ([]webhookSub, error) {
+	ids, err := rds.SMembers(ctx, setWebhooks).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	pipe := rds.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, keyWebhookPrefix+id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	subs := make([]webhookSub, 0, len(ids))
+	for i, cmd := range cmds {
+		h := cmd.Val()
+		if h["url"] == "" {
+			continue
+		}
+		sub := webhookSub{ID: ids[i], URL: h["url"]}
+		if err := json.Unmarshal([]byte(h["miner_ids"]), &sub.MinerIDs); err != nil {
+			continue
+		}
+		sub.MinRateChange, _ = strconv.ParseFloat(h["min_rate_change"], 64)
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// webhookScores reads the current idx:miners:http score of every subscribed miner (missing = not present)
+func webhookScores(ctx context.Context, subs []webhookSub) (map[string]float64, error) {
+	seen := make(map[string]struct{})
+	var miners []string
+	for _, s := range subs {
+		for _, m := range s.MinerIDs {
+			if _, ok := seen[m]; !ok {
+				seen[m] = struct{}{}
+				miners = append(miners, m)
+			}
+		}
+	}
+	pipe := rds.Pipeline()
+	cmds := make([]*redis.FloatCmd, len(miners))
+	for i, m := range miners {
+		cmds[i] = pipe.ZScore(ctx, zsetMinerHTTP, m)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	out := make(map[string]float64, len(miners))
+	for i, cmd := range cmds {
+		if cmd.Err() == nil {
+			out[miners[i]] = cmd.Val()
+		}
+	}
+	return out, nil
+}
+
+// notifyWebhooks compares the scores taken before the cron run with the current ones and delivers
+// the changes. Runs in its own goroutine so slow receivers never delay the cron.
+func notifyWebhooks(subs []webhookSub, before map[string]float64, runAt time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	after, err := webhookScores(ctx, subs)
+	cancel()
+	if err != nil {
+		log.Printf("[webhook] read scores error: %v", err)
+		return
+	}
+
+	for _, sub := range subs {
+		var changes []webhookChange
+		for _, m := range sub.MinerIDs {
+			oldRate, okOld := before[m]
+			newRate, okNew := after[m]
+			if !okOld || !okNew {
+				continue
+			}
+			if d := newRate - oldRate; math.Abs(d) > sub.MinRateChange {
+				changes = append(changes, webhookChange{MinerID: m, OldRate: oldRate, NewRate: newRate, Change: d})
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		go deliverWebhook(sub, changes, runAt)
+	}
+}
+
+func deliverWebhook(sub webhookSub, changes []webhookChange, runAt time.Time) {
+	bz, _ := json.Marshal(map[string]any{
+		"subscription_id": sub.ID,
+		"run_at":          runAt.Format(time.RFC3339),
+		"changes":         changes,
+	})
+
+	var lastErr error
+	for attempt := 1; attempt <= webhookRetries; attempt++ {
+		if lastErr = postWebhook(sub.URL, bz); lastErr == nil {
+			return
+		}
+		log.Printf("[webhook] %s attempt %d/%d failed: %v", sub.ID, attempt, webhookRetries, lastErr)
+		if attempt < webhookRetries {
+			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := deleteWebhook(ctx, sub.ID); err != nil {
+		log.Printf("[webhook] remove undeliverable %s error: %v", sub.ID, err)
+		return
+	}
+	log.Printf("[webhook] removed undeliverable subscription %s (%s): %v", sub.ID, sub.URL, lastErr)
+}
+
+func postWebhook(target string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const weightSumTolerance = 1e-6
+
+// WeightConfig holds the composite score weights (COMPOSITE_WEIGHT_HTTP, _GRAPHSYNC, _BITSWAP)
+type WeightConfig struct {
+	HTTP      float64 `json:"http"`
+	GraphSync float64 `json:"graphsync"`
+	Bitswap   float64 `json:"bitswap"`
+}
+
+// Validate requires non-negative weights that sum to 1 (within weightSumTolerance), so the composite
+// score stays a rate in 0..1
+func (c WeightConfig) Validate() error {
+	if c.HTTP < 0 || c.GraphSync < 0 || c.Bitswap < 0 {
+		return fmt.Errorf("composite weights must not be negative (http=%g graphsync=%g bitswap=%g)", c.HTTP, c.GraphSync, c.Bitswap)
+	}
+	if sum := c.HTTP + c.GraphSync + c.Bitswap; math.Abs(sum-1) > weightSumTolerance {
+		return fmt.Errorf("composite weights must sum to 1, got %g (http=%g graphsync=%g bitswap=%g)", sum, c.HTTP, c.GraphSync, c.Bitswap)
+	}
+	return nil
+}
+
+// parseWeights reads the three weights; defaults favour HTTP, the retrieval path clients use
+func parseWeights(httpW, graphsyncW, bitswapW string) (WeightConfig, error) {
+	var c WeightConfig
+	for _, f := range []struct {
+		name string
+		val  string
+		dst  *float64
+	}{
+		{"COMPOSITE_WEIGHT_HTTP", httpW, &c.HTTP},
+		{"COMPOSITE_WEIGHT_GRAPHSYNC", graphsyncW, &c.GraphSync},
+		{"COMPOSITE_WEIGHT_BITSWAP", bitswapW, &c.Bitswap},
+	} {
+		v, err := strconv.ParseFloat(f.val, 64)
+		if err != nil {
+			return c, fmt.Errorf("%s %q is not a number", f.name, f.val)
+		}
+		*f.dst = v
+	}
+	return c, c.Validate()
+}
@@ -120,7 +120,9 @@ func run(c *cli.Context) error {
 	documents := underscore.Map(rows, func(row Row) model.DBClaim {
 		return row.Document
 	})
-	tasks, results := util.AddTasks(ctx, requester, ipInfo, documents, locationResolver, *providerResolver)
+	tasks, results, stats := util.AddTasks(ctx, requester, ipInfo, documents, locationResolver, *providerResolver)
+	logger.Infow("Tasks generated", "tasks", len(tasks), "results", len(results),
+		"validation_errors", stats.ValidationErrors)
 
 	taskClient, err := mongo.
 		Connect(ctx, options.Client().ApplyURI(env.GetRequiredString(env.QueueMongoURI)))
@@ -0,0 +1,93 @@
+// Package claimstore holds the MongoDB maintenance of the claims collection written by
+// integration/claims, shared with the query server.
+package claimstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Filecoin mainnet genesis (2020-08-25 22:00:00 UTC), 30s epochs
+	MainnetGenesisUnix = 1598306400
+	EpochSeconds       = 30
+	EpochsPerDay       = 24 * 60 * 60 / EpochSeconds
+
+	// PruneBatchSize is the number of claims removed per DeleteMany
+	PruneBatchSize = 10000
+)
+
+// Prune status selectors; an empty status prunes both kinds
+const (
+	PruneExpired = "expired" // term_start + term_max ended more than older_than_days ago
+	PruneDeleted = "deleted" // soft-deleted (deleted_at) more than older_than_days ago
+)
+
+// CurrentEpoch derives the mainnet chain epoch from the wall clock
+func CurrentEpoch(now time.Time) int64 {
+	return (now.Unix() - MainnetGenesisUnix) / EpochSeconds
+}
+
+// PruneFilter returns the filter of claims that are prunable for status ("", expired or deleted)
+func PruneFilter(status string, olderThanDays int, now time.Time) (bson.M, error) {
+	if olderThanDays <= 0 {
+		return nil, fmt.Errorf("older_than_days must be positive, got %d", olderThanDays)
+	}
+	deleted := bson.M{"deleted_at": bson.M{"$lt": now.AddDate(0, 0, -olderThanDays)}}
+	cutoff := CurrentEpoch(now) - int64(olderThanDays)*EpochsPerDay
+	expired := bson.M{"$expr": bson.M{"$lt": bson.A{bson.M{"$add": bson.A{"$term_start", "$term_max"}}, cutoff}}}
+
+	switch status {
+	case PruneDeleted:
+		return deleted, nil
+	case PruneExpired:
+		return expired, nil
+	case "":
+		return bson.M{"$or": bson.A{deleted, expired}}, nil
+	default:
+		return nil, fmt.Errorf("status must be %s or %s", PruneExpired, PruneDeleted)
+	}
+}
+
+// Prune deletes the claims matched by filter in batches of PruneBatchSize, so a large prune never
+// holds one long-running DeleteMany on the collection. It returns the number of deleted claims.
+func Prune(ctx context.Context, coll *mongo.Collection, filter bson.M) (int64, error) {
+	findOpts := options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetLimit(PruneBatchSize)
+
+	var total int64
+	for {
+		cur, err := coll.Find(ctx, filter, findOpts)
+		if err != nil {
+			return total, err
+		}
+		var docs []struct {
+			ID any `bson:"_id"`
+		}
+		if err := cur.All(ctx, &docs); err != nil {
+			return total, err
+		}
+		if len(docs) == 0 {
+			return total, nil
+		}
+
+		ids := make(bson.A, len(docs))
+		for i, d := range docs {
+			ids[i] = d.ID
+		}
+		res, err := coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return total, err
+		}
+		total += res.DeletedCount
+		if len(docs) < PruneBatchSize {
+			return total, nil
+		}
+	}
+}
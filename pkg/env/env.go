@@ -12,37 +12,42 @@ type Key string
 
 //nolint:gosec
 const (
-	ProcessModules                Key = "PROCESS_MODULES"
-	ProcessErrorInterval          Key = "PROCESS_ERROR_INTERVAL"
-	TaskWorkerPollInterval        Key = "TASK_WORKER_POLL_INTERVAL"
-	TaskWorkerTimeoutBuffer       Key = "TASK_WORKER_TIMEOUT_BUFFER"
-	LotusAPIUrl                   Key = "LOTUS_API_URL"
-	LotusAPIToken                 Key = "LOTUS_API_TOKEN"
-	QueueMongoURI                 Key = "QUEUE_MONGO_URI"
-	QueueMongoDatabase            Key = "QUEUE_MONGO_DATABASE"
-	ResultMongoURI                Key = "RESULT_MONGO_URI"
-	ResultMongoDatabase           Key = "RESULT_MONGO_DATABASE"
-	FilplusIntegrationBatchSize   Key = "FILPLUS_INTEGRATION_BATCH_SIZE"
-	FilplusIntegrationTaskTimeout Key = "FILPLUS_INTEGRATION_TASK_TIMEOUT"
-	FilplusIntegrationRandConst   Key = "FILPLUS_INTEGRATION_RANDOM_CONSTANT"
-	StatemarketdealsMongoURI      Key = "STATEMARKETDEALS_MONGO_URI"
-	StatemarketdealsMongoDatabase Key = "STATEMARKETDEALS_MONGO_DATABASE"
-	StatemarketdealsBatchSize     Key = "STATEMARKETDEALS_BATCH_SIZE"
-	StatemarketdealsInterval      Key = "STATEMARKETDEALS_INTERVAL"
-	PublicIP                      Key = "_PUBLIC_IP"
-	City                          Key = "_CITY"
-	Region                        Key = "_REGION"
-	Country                       Key = "_COUNTRY"
-	Continent                     Key = "_CONTINENT"
-	ASN                           Key = "_ASN"
-	ISP                           Key = "_ISP"
-	Latitude                      Key = "_LATITUDE"
-	Longitude                     Key = "_LONGITUDE"
-	ProviderCacheTTL              Key = "PROVIDER_CACHE_TTL"
-	LocationCacheTTL              Key = "LOCATION_CACHE_TTL"
-	AcceptedContinents            Key = "ACCEPTED_CONTINENTS"
-	AcceptedCountries             Key = "ACCEPTED_COUNTRIES"
-	IPInfoToken                   Key = "IPINFO_TOKEN"
+	ProcessModules                  Key = "PROCESS_MODULES"
+	ProcessErrorInterval            Key = "PROCESS_ERROR_INTERVAL"
+	TaskWorkerPollInterval          Key = "TASK_WORKER_POLL_INTERVAL"
+	TaskWorkerTimeoutBuffer         Key = "TASK_WORKER_TIMEOUT_BUFFER"
+	LotusAPIUrl                     Key = "LOTUS_API_URL"
+	LotusAPIToken                   Key = "LOTUS_API_TOKEN"
+	QueueMongoURI                   Key = "QUEUE_MONGO_URI"
+	QueueMongoDatabase              Key = "QUEUE_MONGO_DATABASE"
+	ResultMongoURI                  Key = "RESULT_MONGO_URI"
+	ResultMongoDatabase             Key = "RESULT_MONGO_DATABASE"
+	FilplusIntegrationBatchSize     Key = "FILPLUS_INTEGRATION_BATCH_SIZE"
+	FilplusIntegrationTaskTimeout   Key = "FILPLUS_INTEGRATION_TASK_TIMEOUT"
+	FilplusHTTPTaskTimeoutMS        Key = "FILPLUS_HTTP_TASK_TIMEOUT_MS"
+	FilplusGraphsyncTaskTimeoutMS   Key = "FILPLUS_GRAPHSYNC_TASK_TIMEOUT_MS"
+	FilplusBitswapTaskTimeoutMS     Key = "FILPLUS_BITSWAP_TASK_TIMEOUT_MS"
+	FilplusIntegrationRandConst     Key = "FILPLUS_INTEGRATION_RANDOM_CONSTANT"
+	FilplusIntegrationRetrievalSize Key = "FILPLUS_INTEGRATION_RETRIEVAL_SIZE"
+	StatemarketdealsMongoURI        Key = "STATEMARKETDEALS_MONGO_URI"
+	StatemarketdealsMongoDatabase   Key = "STATEMARKETDEALS_MONGO_DATABASE"
+	StatemarketdealsBatchSize       Key = "STATEMARKETDEALS_BATCH_SIZE"
+	StatemarketdealsInterval        Key = "STATEMARKETDEALS_INTERVAL"
+	PublicIP                        Key = "_PUBLIC_IP"
+	City                            Key = "_CITY"
+	Region                          Key = "_REGION"
+	Country                         Key = "_COUNTRY"
+	Continent                       Key = "_CONTINENT"
+	ASN                             Key = "_ASN"
+	ISP                             Key = "_ISP"
+	Latitude                        Key = "_LATITUDE"
+	Longitude                       Key = "_LONGITUDE"
+	ProviderCacheTTL                Key = "PROVIDER_CACHE_TTL"
+	LocationCacheTTL                Key = "LOCATION_CACHE_TTL"
+	AcceptedContinents              Key = "ACCEPTED_CONTINENTS"
+	AcceptedCountries               Key = "ACCEPTED_COUNTRIES"
+	IPInfoToken                     Key = "IPINFO_TOKEN"
+	FilecoinNetwork                 Key = "FILECOIN_NETWORK"
 )
 
 func GetString(key Key, defaultValue string) string {
@@ -0,0 +1,43 @@
+package env
+
+import (
+	"fmt"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// ApplyMongoConsistency sets the MONGO_WRITE_CONCERN (wc) and MONGO_READ_PREFERENCE (rp) values on the
+// client options; "" keeps the driver default
+func ApplyMongoConsistency(o *options.ClientOptions, wc, rp string) error {
+	switch wc {
+	case "":
+	case "majority":
+		o.SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+	default:
+		n, err := strconv.Atoi(wc)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid MONGO_WRITE_CONCERN %q (want a number >= 1 or \"majority\")", wc)
+		}
+		o.SetWriteConcern(writeconcern.New(writeconcern.W(n)))
+	}
+
+	switch rp {
+	case "":
+	case "primary":
+		o.SetReadPreference(readpref.Primary())
+	case "primaryPreferred":
+		o.SetReadPreference(readpref.PrimaryPreferred())
+	case "secondary":
+		o.SetReadPreference(readpref.Secondary())
+	case "secondaryPreferred":
+		o.SetReadPreference(readpref.SecondaryPreferred())
+	case "nearest":
+		o.SetReadPreference(readpref.Nearest())
+	default:
+		return fmt.Errorf("invalid MONGO_READ_PREFERENCE %q (want primary, primaryPreferred, secondary, secondaryPreferred or nearest)", rp)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package env
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"testing"
+)
+
+func TestApplyMongoConsistency(t *testing.T) {
+	o := options.Client()
+	assert.NoError(t, ApplyMongoConsistency(o, "", ""))
+	assert.Nil(t, o.WriteConcern)
+	assert.Nil(t, o.ReadPreference)
+
+	o = options.Client()
+	assert.NoError(t, ApplyMongoConsistency(o, "majority", "secondaryPreferred"))
+	assert.NotNil(t, o.WriteConcern)
+	assert.Equal(t, readpref.SecondaryPreferredMode, o.ReadPreference.Mode())
+
+	o = options.Client()
+	assert.NoError(t, ApplyMongoConsistency(o, "2", "nearest"))
+	assert.Equal(t, readpref.NearestMode, o.ReadPreference.Mode())
+
+	for _, wc := range []string{"0", "-1", "all"} {
+		assert.Error(t, ApplyMongoConsistency(options.Client(), wc, ""), wc)
+	}
+	assert.Error(t, ApplyMongoConsistency(options.Client(), "", "secondary_preferred"))
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------
+// Miner ID addresses: f0<id> on mainnet, t0<id> on calibnet
+// -----------------------------
+const (
+	NetworkMainnet  = "mainnet"
+	NetworkCalibnet = "calibnet"
+)
+
+// NormalizeMinerAddr builds the ID address of a miner actor for the given network.
+// Unknown or empty network IDs are treated as mainnet.
+func NormalizeMinerAddr(networkID string, actorID uint64) string {
+	return networkPrefix(networkID) + "0" + strconv.FormatUint(actorID, 10)
+}
+
+// NormalizeMinerAddrString re-formats an existing ID address ("f0123", "t00123", "0123")
+// for the given network, dropping leading zeros of the actor ID.
+func NormalizeMinerAddrString(networkID string, addr string) (string, error) {
+	s := strings.TrimSpace(addr)
+	if len(s) > 0 && (s[0] == 'f' || s[0] == 't') {
+		s = s[1:]
+	}
+	if len(s) < 2 || s[0] != '0' {
+		return "", fmt.Errorf("not an ID address: %q", addr)
+	}
+	id, err := strconv.ParseUint(s[1:], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("not an ID address: %q: %w", addr, err)
+	}
+	return NormalizeMinerAddr(networkID, id), nil
+}
+
+func networkPrefix(networkID string) string {
+	switch strings.ToLower(networkID) {
+	case NetworkCalibnet, "calibrationnet", "testnet":
+		return "t"
+	default:
+		return "f"
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeMinerAddr(t *testing.T) {
+	assert.Equal(t, "f01000", NormalizeMinerAddr(NetworkMainnet, 1000))
+	assert.Equal(t, "t01000", NormalizeMinerAddr(NetworkCalibnet, 1000))
+	assert.Equal(t, "f00", NormalizeMinerAddr("", 0))
+	assert.Equal(t, "t00", NormalizeMinerAddr(NetworkCalibnet, 0))
+}
+
+func TestNormalizeMinerAddrString(t *testing.T) {
+	for _, network := range []string{NetworkMainnet, NetworkCalibnet} {
+		prefix := "f0"
+		if network == NetworkCalibnet {
+			prefix = "t0"
+		}
+		for in, id := range map[string]string{
+			"f01234":   "1234",
+			"t01234":   "1234",
+			"f0001234": "1234",
+			"t0001234": "1234",
+			"001234":   "1234",
+			"f000":     "0",
+		} {
+			got, err := NormalizeMinerAddrString(network, in)
+			assert.NoError(t, err, in)
+			assert.Equal(t, prefix+id, got, in)
+		}
+	}
+
+	for _, in := range []string{"", "f0", "f1abc", "f0x12", "f3xyz"} {
+		_, err := NormalizeMinerAddrString(NetworkMainnet, in)
+		assert.Error(t, err, in)
+	}
+}
@@ -7,29 +7,73 @@ import (
 	"strings"
 )
 
+// ErrorCode classifies a failed retrieval; it is stored in result.error_code.
 type ErrorCode string
 
 const (
-	ErrorCodeNone                  ErrorCode = ""
-	InvalidPeerID                  ErrorCode = "invalid_peerid"
-	NoValidMultiAddrs              ErrorCode = "no_valid_multiaddrs"
-	CannotConnect                  ErrorCode = "cannot_connect"
-	NotFound                       ErrorCode = "not_found"
-	RetrievalFailure               ErrorCode = "retrieval_failure"
-	ProtocolNotSupported           ErrorCode = "protocol_not_supported"
-	Timeout                        ErrorCode = "timeout"
+	// ErrorCodeNone means the error could not be classified (or there was no error).
+	ErrorCodeNone ErrorCode = ""
+	// InvalidPeerID is set at task creation when the provider's on-chain peer ID does not decode.
+	InvalidPeerID ErrorCode = "invalid_peerid"
+	// NoValidMultiAddrs is set when the provider has no usable address: invalid or bogon IPs,
+	// failed host lookups, or no multiaddrs at all.
+	NoValidMultiAddrs ErrorCode = "no_valid_multiaddrs"
+	// CannotConnect is set when the worker cannot open a connection to the provider.
+	CannotConnect ErrorCode = "cannot_connect"
+	// NotFound is set when the provider does not have the content or no unsealed copy of it.
+	NotFound ErrorCode = "not_found"
+	// RetrievalFailure is set when the stream breaks while transferring data.
+	RetrievalFailure ErrorCode = "retrieval_failure"
+	// ProtocolNotSupported is set when the provider does not advertise the module's protocol.
+	ProtocolNotSupported ErrorCode = "protocol_not_supported"
+	// Timeout is set when the task runs past its deadline.
+	Timeout ErrorCode = "timeout"
+	// DealRejectedPricePerByteTooLow is set when the provider rejects the deal's price per byte.
 	DealRejectedPricePerByteTooLow ErrorCode = "deal_rejected_price_per_byte_too_low"
-	DealRejectedUnsealPriceTooLow  ErrorCode = "deal_rejected_unseal_price_too_low"
-	Throttled                      ErrorCode = "throttled"
-	NoAccess                       ErrorCode = "no_access"
-	UnderMaintenance               ErrorCode = "under_maintenance"
-	NotOnline                      ErrorCode = "not_online"
-	UnconfirmedBlockTransfer       ErrorCode = "unconfirmed_block_transfer"
-	CIDCodecNotSupported           ErrorCode = "cid_codec_not_supported"
-	ResponseRejected               ErrorCode = "response_rejected"
-	DealStateMissing               ErrorCode = "deal_state_missing"
+	// DealRejectedUnsealPriceTooLow is set when the provider rejects the deal's unseal price.
+	DealRejectedUnsealPriceTooLow ErrorCode = "deal_rejected_unseal_price_too_low"
+	// Throttled is set when the provider has too many retrieval deals in flight.
+	Throttled ErrorCode = "throttled"
+	// NoAccess is set when the provider's access control refuses the retrieval.
+	NoAccess ErrorCode = "no_access"
+	// UnderMaintenance is set when the provider reports it is under maintenance.
+	UnderMaintenance ErrorCode = "under_maintenance"
+	// NotOnline is set when the provider does not accept online retrieval deals.
+	NotOnline ErrorCode = "not_online"
+	// UnconfirmedBlockTransfer is set when the provider sends blocks that were not requested.
+	UnconfirmedBlockTransfer ErrorCode = "unconfirmed_block_transfer"
+	// CIDCodecNotSupported is set when the content uses a codec the worker cannot decode.
+	CIDCodecNotSupported ErrorCode = "cid_codec_not_supported"
+	// ResponseRejected is set when the provider rejects the retrieval request.
+	ResponseRejected ErrorCode = "response_rejected"
+	// DealStateMissing is set when the provider cannot find the storage deal state.
+	DealStateMissing ErrorCode = "deal_state_missing"
 )
 
+// KnownErrorCodes returns every defined error code except ErrorCodeNone, e.g. as a legend for
+// result breakdowns.
+func KnownErrorCodes() []ErrorCode {
+	return []ErrorCode{
+		InvalidPeerID,
+		NoValidMultiAddrs,
+		CannotConnect,
+		NotFound,
+		RetrievalFailure,
+		ProtocolNotSupported,
+		Timeout,
+		DealRejectedPricePerByteTooLow,
+		DealRejectedUnsealPriceTooLow,
+		Throttled,
+		NoAccess,
+		UnderMaintenance,
+		NotOnline,
+		UnconfirmedBlockTransfer,
+		CIDCodecNotSupported,
+		ResponseRejected,
+		DealStateMissing,
+	}
+}
+
 var errorStringMap = map[string]ErrorCode{
 	"Price per byte too low":                        DealRejectedPricePerByteTooLow,
 	"Unseal price too small":                        DealRejectedUnsealPriceTooLow,
@@ -14,3 +14,15 @@ func TestResolveError(t *testing.T) {
 	result := resolveErrorResult(err)
 	assert.NotNil(t, result)
 }
+
+func TestKnownErrorCodesCoversStringMap(t *testing.T) {
+	known := make(map[ErrorCode]bool)
+	for _, code := range KnownErrorCodes() {
+		assert.False(t, known[code], "duplicate %s", code)
+		known[code] = true
+	}
+	assert.False(t, known[ErrorCodeNone])
+	for _, code := range errorStringMap {
+		assert.True(t, known[code], "%s missing from KnownErrorCodes", code)
+	}
+}
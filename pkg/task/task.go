@@ -1,6 +1,7 @@
 package task
 
 import (
+	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/pkg/errors"
 	"storagestats/pkg/convert"
@@ -16,6 +17,8 @@ type Provider struct {
 	Region     string   `bson:"region,omitempty"`
 	Country    string   `bson:"country,omitempty"`
 	Continent  string   `bson:"continent,omitempty"`
+	ASN        string   `bson:"asn,omitempty"`
+	ISP        string   `bson:"isp,omitempty"`
 }
 
 func (p Provider) GetPeerAddr() (peer.AddrInfo, error) {
@@ -44,8 +47,19 @@ const (
 	Bitswap   ModuleName = "bitswap"
 )
 
+// Retrieve types of Content.RetrieveType
+const (
+	RetrievePiece     = "piece"
+	RetrieveRootBlock = "root_block"
+)
+
 type Content struct {
 	CID string `bson:"cid"`
+	// Mirrors the retrieve_type metadata, so processors don't have to parse strings
+	RetrieveType string `bson:"retrieve_type,omitempty"`
+	// Bytes to retrieve (HTTP piece retrieval); 0 = worker default. Replaces the deprecated
+	// retrieve_size metadata string, which workers still read for tasks queued before this field.
+	RetrievalSizeBytes int64 `bson:"retrieve_size,omitempty"`
 }
 
 type Task struct {
@@ -57,3 +71,23 @@ type Task struct {
 	Timeout   time.Duration     `bson:"timeout,omitempty"`
 	CreatedAt time.Time         `bson:"created_at"`
 }
+
+// Validate checks that the task carries everything a worker needs to run it.
+func (t Task) Validate() error {
+	if t.Module == "" {
+		return errors.New("module is empty")
+	}
+	if t.Content.CID == "" {
+		return errors.New("content cid is empty")
+	}
+	if _, err := cid.Decode(t.Content.CID); err != nil {
+		return errors.Wrap(err, "invalid content cid")
+	}
+	if t.Provider.ID == "" {
+		return errors.New("provider id is empty")
+	}
+	if _, err := peer.Decode(t.Provider.PeerID); err != nil {
+		return errors.Wrap(err, "invalid provider peer id")
+	}
+	return nil
+}
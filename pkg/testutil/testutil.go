@@ -0,0 +1,116 @@
+// Package testutil gives tests a Redis client and a MongoDB database that are cleaned up automatically.
+//
+// Redis is an in-process miniredis server, one per test. MongoDB runs in a container started with
+// testcontainers-go on first use and shared by every test of the package binary (each test gets a database
+// of its own through TestDatabase); it needs a Docker daemon, tests calling StartTestMongo are skipped
+// without one, and the Ryuk reaper removes the container when the test binary exits.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	connectTimeout = 5 * time.Second
+	mongoImage     = "mongo:6"
+	mongoStartup   = 2 * time.Minute // includes the image pull on a fresh machine
+)
+
+// StartTestRedis starts a miniredis server for the test; server and client are closed in t.Cleanup
+func StartTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	s := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+var (
+	mongoOnce sync.Once
+	mongoURI  string
+	mongoErr  error
+)
+
+// mongoContainerURI starts the shared MongoDB container once and returns its connection string
+func mongoContainerURI() (string, error) {
+	mongoOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mongoStartup)
+		defer cancel()
+		c, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+			ContainerRequest: testcontainers.ContainerRequest{
+				Image:        mongoImage,
+				ExposedPorts: []string{"27017/tcp"},
+				WaitingFor: wait.ForAll(
+					wait.ForLog("Waiting for connections"),
+					wait.ForListeningPort("27017/tcp"),
+				),
+			},
+			Started: true,
+		})
+		if err != nil {
+			mongoErr = fmt.Errorf("start %s container: %w", mongoImage, err)
+			return
+		}
+		endpoint, err := c.PortEndpoint(ctx, "27017/tcp", "mongodb")
+		if err != nil {
+			mongoErr = fmt.Errorf("%s container endpoint: %w", mongoImage, err)
+			return
+		}
+		mongoURI = endpoint
+	})
+	return mongoURI, mongoErr
+}
+
+// StartTestMongo connects to the shared MongoDB container, skipping the test if it can't be started;
+// the client is disconnected in t.Cleanup.
+// Use TestDatabase for a database of the test's own.
+func StartTestMongo(t *testing.T) *mongo.Client {
+	t.Helper()
+	uri, err := mongoContainerURI()
+	if err != nil {
+		t.Skipf("mongo unavailable: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("mongo connect: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		t.Fatalf("mongo ping: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Disconnect(context.Background()) })
+	return client
+}
+
+// TestDatabase returns a fresh database named after the test, dropped in t.Cleanup
+// (registered after StartTestMongo's, so it runs before the client is disconnected)
+func TestDatabase(t *testing.T, client *mongo.Client) *mongo.Database {
+	t.Helper()
+	name := strings.NewReplacer("/", "_", " ", "_", ".", "_").Replace(t.Name())
+	name = fmt.Sprintf("test_%d_%s", time.Now().UnixNano(), name)
+	if len(name) > 63 { // MongoDB database name limit is 64 bytes
+		name = name[:63]
+	}
+	db := client.Database(name)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		defer cancel()
+		_ = db.Drop(ctx)
+	})
+	return db
+}
@@ -137,7 +137,9 @@ func (e Worker) DoWork(tsk task.Task) (*task.RetrievalResult, error) {
 	}
 
 	size := 1024 * 1024
-	if sizeStr, ok := tsk.Metadata["retrieve_size"]; ok {
+	if tsk.Content.RetrievalSizeBytes > 0 {
+		size = int(tsk.Content.RetrievalSizeBytes)
+	} else if sizeStr, ok := tsk.Metadata["retrieve_size"]; ok {
 		size, err = strconv.Atoi(sizeStr)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to convert retrieve_size to int")